@@ -1,45 +1,142 @@
 package cli
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/internal/console"
 	"github.com/thushan/smash/internal/smash"
 	"github.com/thushan/smash/internal/theme"
+	"github.com/thushan/smash/pkg/algorithms"
 	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/manifest"
 	"github.com/thushan/smash/pkg/slicer"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/thediveo/enumflag/v2"
 )
 
 var (
-	af      *smash.Flags
-	rootCmd = &cobra.Command{
+	af       *smash.Flags
+	logger   *slog.Logger
+	closeLog = func() error { return nil }
+	rootCmd  = &cobra.Command{
 		Use:          "smash [flags] [locations-to-smash]",
+		Args:         cobra.ArbitraryArgs,
 		Short:        "Find duplicates fast!",
 		Long:         "",
 		SilenceUsage: true,
 		RunE:         runE,
+		PersistentPreRunE: func(command *cobra.Command, args []string) error {
+			// keep the defaults when the log can't be opened, Main still needs them
+			l, closer, err := console.NewLogger(af)
+			if err != nil {
+				return err
+			}
+			logger, closeLog = l, closer
+			return nil
+		},
+	}
+	verifyCmd = &cobra.Command{
+		Use:   "verify [flags] manifest [locations-with-extra-files]",
+		Short: "Verify files against a checksum manifest (sha256sum, bsd or hashdeep)",
+		Long: "Verify files against a checksum manifest, reporting missing & changed files.\n" +
+			"Relative paths are resolved from the current directory like sha256sum -c.\n" +
+			"Any locations given are searched for extra files that aren't in the manifest.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: verifyE,
+	}
+	watchCmd = &cobra.Command{
+		Use:   "watch [flags] [locations-to-watch]",
+		Short: "Scan locations then report duplicates as files arrive",
+		Long: "Scan locations like smash, then keep watching them & rehash files as they're created or modified,\n" +
+			"reporting each new duplicate as it appears. Stop with Ctrl+C for the usual summary & report.",
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		RunE:         watchE,
+	}
+	serveCmd = &cobra.Command{
+		Use:   "serve [flags]",
+		Short: "Serve a local HTTP/JSON API to start scans, poll their progress & fetch reports",
+		Long: "Serve a local HTTP/JSON API to start scans, poll their progress, cancel them & fetch reports.\n" +
+			"Scans use the flags given to serve unless the request overrides them, see the user guide.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         serveE,
+	}
+	reviewCmd = &cobra.Command{
+		Use:   "review report",
+		Short: "Review the duplicates in a report, marking copies to keep, delete or link",
+		Long: "Browse the duplicates in a smash report, largest reclaimable groups first, marking files to keep,\n" +
+			"delete or hard link by file or by directory. Nothing changes until the marked files are applied & confirmed,\n" +
+			"files that changed since the report was made are left alone.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			report, err := smash.ReadReport(args[0])
+			if err != nil {
+				return err
+			}
+			return console.RunReview(report)
+		},
+	}
+	schemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the analysis report",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, args []string) error {
+			_, err := command.OutOrStdout().Write(smash.ReportSchema)
+			return err
+		},
 	}
 )
 
 func init() {
-	af = &smash.Flags{}
+	af = &smash.Flags{Algorithms: []int{int(algorithms.Xxhash)}}
 	rootCmd.SilenceErrors = true
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(reviewCmd)
+	verifyCmd.Flags().IntVarP(&af.MaxWorkers, "max-workers", "w", runtime.NumCPU(), "Maximum workers to utilise when verifying")
 	rootCmd.PersistentFlags().Var(
-		enumflag.New(&af.Algorithm, "algorithm", algorithms.HashAlgorithms, enumflag.EnumCaseInsensitive),
+		enumflag.NewSlice(&af.Algorithms, "algorithm", algorithms.Names(), enumflag.EnumCaseInsensitive),
 		"algorithm",
-		"Algorithm to use to hash files. Supported: xxhash, murmur3, md5, sha512, sha256 (full list, see readme)")
-	flags := rootCmd.Flags()
+		"Algorithms to use to hash files, duplicates are found by the first & the rest are reported as extra digests Eg. --algorithm=sha256,md5. Supported: xxhash, xxh3-128, blake3, murmur3, crc32c, md5, sha1, sha512, sha256 (full list, see readme)")
+	rootCmd.PersistentFlags().StringVarP(&af.LogFormat, "log-format", "", "", "Log as structured text or json lines for log aggregation (default is the console) Eg. --log-format=json")
+	rootCmd.PersistentFlags().StringVarP(&af.LogFile, "log-file", "", "", "Append logs to this file instead of stderr, as text unless --log-format is given")
+	addSmashFlags(rootCmd.Flags())
+	rootCmd.Flags().StringVarP(&af.CheckpointFile, "checkpoint", "", "", "Record smashed files to a state file so an interrupted scan can be resumed with --resume")
+	rootCmd.Flags().StringVarP(&af.ResumeFile, "resume", "", "", "Resume the scan recorded in a state file by --checkpoint, skipping files already smashed")
+	rootCmd.Flags().DurationVarP(&af.CheckpointInterval, "checkpoint-interval", "", smash.DefaultCheckpointInterval, "How often the state file is written to disk")
+	rootCmd.Flags().IntVarP(&af.RetryFailed, "retry-failed", "", 0, "Retry files that failed with a transient error (io, timeout, busy, unstable) up to x times once smashing finishes")
+	rootCmd.Flags().DurationVarP(&af.RetryBackoff, "retry-backoff", "", smash.DefaultRetryBackoff, "How long to wait before the first retry, doubling each retry after that")
+	rootCmd.Flags().BoolVarP(&af.Interactive, "interactive", "i", false, "Review the duplicates once smashing finishes, marking copies to keep, delete or link (see smash review)")
+	addSmashFlags(watchCmd.Flags())
+	addSmashFlags(serveCmd.Flags())
+	serveCmd.Flags().StringVarP(&af.ServeAddress, "addr", "", smash.DefaultServeAddress, "Address to serve the API on, anyone who can reach it can read any file smash can")
+	serveCmd.Flags().IntVarP(&af.ServeRetain, "retain", "", smash.DefaultServeRetain, "How many finished scans to keep, older scans & their reports are forgotten")
+	watchCmd.Flags().DurationVarP(&af.WatchSettle, "settle", "", smash.DefaultWatchSettle, "How long a file must be left alone before it's rehashed")
+	watchCmd.Flags().BoolVarP(&af.WatchJSON, "json", "", false, "Print events as JSON lines, combine with --silent to only print events")
+}
+
+func addSmashFlags(flags *pflag.FlagSet) {
 	flags.StringSliceVarP(&af.Base, "base", "", nil, "Base directories to use for comparison Eg. --base=/c/dos,/c/dos/run/,/run/dos/run")
 	flags.StringSliceVarP(&af.ExcludeFile, "exclude-file", "", nil, "Files to exclude separated by comma Eg. --exclude-file=.gitignore,*.csv")
 	flags.StringSliceVarP(&af.ExcludeDir, "exclude-dir", "", nil, "Directories to exclude separated by comma Eg. --exclude-dir=.git,.idea")
 	flags.IntVarP(&af.MaxThreads, "max-threads", "p", runtime.NumCPU(), "Maximum threads to utilise")
 	flags.IntVarP(&af.MaxWorkers, "max-workers", "w", runtime.NumCPU(), "Maximum workers to utilise when smashing")
+	flags.IntVarP(&af.HddWorkers, "hdd-workers", "", 0, "Maximum workers per rotational disk, they're always read in inode order (0 = their share of max-workers)")
+	flags.StringToIntVarP(&af.LocationWorkers, "location-workers", "", nil, "Maximum concurrent reads per location Eg. --location-workers=/mnt/nas=4,/mnt/usb=1")
 	flags.Int64VarP(&af.MinSize, "min-size", "G", 0, "Minimum file size to consider for hashing (in bytes)")
 	flags.Int64VarP(&af.MaxSize, "max-size", "L", 0, "Maximum file size to consider for hashing (in bytes)")
 	flags.IntVarP(&af.ProgressUpdate, "progress-update", "", 5, "Update progress every x seconds")
@@ -49,6 +146,7 @@ func init() {
 	flags.BoolVarP(&af.DisableSlicing, "disable-slicing", "", false, "Disable slicing & hash the full file instead")
 	flags.BoolVarP(&af.DisableMeta, "disable-meta", "", false, "Disable storing of meta-data to improve hashing mismatches")
 	flags.BoolVarP(&af.DisableAutoText, "disable-autotext", "", false, "Disable detecting text-files to opt for a full hash for those")
+	flags.BoolVarP(&af.DisableFormats, "disable-formats", "", false, "Disable format-aware sampling (video, zip) & slice every file uniformly")
 	flags.BoolVarP(&af.IgnoreEmpty, "ignore-empty", "", true, "Ignore empty/zero byte files")
 	flags.BoolVarP(&af.IgnoreHidden, "ignore-hidden", "", true, "Ignore hidden files & folders Eg. files/folders starting with '.'")
 	flags.BoolVarP(&af.IgnoreSystem, "ignore-system", "", true, "Ignore system files & folders Eg. '$MFT', '.Trash'")
@@ -56,12 +154,24 @@ func init() {
 	flags.BoolVarP(&af.Recurse, "recurse", "r", false, "Recursively search directories for files")
 	flags.BoolVarP(&af.Verbose, "verbose", "", false, "Run in verbose mode")
 	flags.BoolVarP(&af.Profile, "profile", "", false, "Enable Go Profiler - see localhost:1984/debug/pprof")
+	flags.DurationVarP(&af.Timeout, "timeout", "", 0, "Stop smashing after this long & report what was smashed Eg. --timeout=2h (0 = no limit)")
+	flags.StringVarP(&af.MetricsAddress, "metrics-addr", "", "", "Serve Prometheus metrics on this address while smashing Eg. --metrics-addr=:9100 (see /metrics)")
+	flags.StringVarP(&af.MetricsPush, "metrics-push", "", "", "Push Prometheus metrics to this Pushgateway once smashing finishes Eg. --metrics-push=http://pushgateway:9091")
 	flags.BoolVarP(&af.HideProgress, "no-progress", "", false, "Disable progress updates")
 	flags.BoolVarP(&af.HideOutput, "no-output", "", false, "Disable report output")
+	flags.BoolVarP(&af.ReportSkipped, "report-skipped", "", false, "List every file & directory that wasn't smashed in the report with the reason, they're always counted")
 	flags.BoolVarP(&af.ShowNerdStats, "nerd-stats", "", false, "Show nerd stats")
 	flags.BoolVarP(&af.ShowVersion, "version", "v", false, "Show version information")
 	flags.StringVarP(&af.OutputFile, "output-file", "o", "", "Export analysis as JSON (generated automatically like ./report-*.json)")
+	flags.StringSliceVarP(&af.Against, "against", "", nil, "Match files against known hashes from checksum manifests or smash reports Eg. --against=archive.sha256,known-bad.txt")
+	flags.StringVarP(&af.ManifestFile, "export-manifest", "", "", "Export a checksum manifest of every full hashed file, see --manifest-format")
+	flags.VarP(
+		enumflag.New(&af.ManifestFormat, "format", manifest.Formats, enumflag.EnumCaseInsensitive),
+		"manifest-format", "",
+		"Format of --export-manifest. Supported: sha256sum (gnu, md5/sha1/sha256/sha512 only), bsd, hashdeep")
 	flags.IntVarP(&af.Slices, "slices", "", slicer.DefaultSlices, "Number of Slices to use")
+	flags.BoolVarP(&af.ParallelReads, "parallel-reads", "", false, "Read the slices of a file concurrently (helps high-latency storage Eg. NFS, RAID)")
+	flags.BoolVarP(&af.AdaptiveSlices, "adaptive-slices", "", false, "Scale the number of slices with file size (up to 128)")
 	flags.Int64VarP(&af.SliceSize, "slice-size", "", slicer.DefaultSliceSize, "Size of a Slice (in bytes)")
 	flags.Int64VarP(&af.SliceThreshold, "slice-threshold", "", slicer.DefaultThreshold, "Threshold to use for slicing (in bytes) - if file is smaller than this, it won't be sliced")
 }
@@ -69,54 +179,127 @@ func init() {
 func Main() {
 	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
 	log.SetOutput(os.Stdout)
-	if err := rootCmd.Execute(); err != nil {
+
+	// Ctrl+C stops smashing & reports what was smashed, a second one exits straight away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	if err := execute(ctx, os.Args[1:]); err != nil {
 		theme.Error.Println(err)
 		os.Exit(1)
 	}
 }
 
+// execute Runs the command for the args, logging a failure to the structured log when there is one.
+func execute(ctx context.Context, args []string) error {
+	rootCmd.SetArgs(args)
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil && logger != nil && (af.LogFormat != "" || af.LogFile != "") {
+		logger.Error("smash failed", "error", err)
+	}
+	_ = closeLog()
+	return err
+}
+
 func runE(command *cobra.Command, args []string) error {
 
-	var locations []indexer.LocationFS
+	locations := smashLocations(args)
 
-	if len(args) == 0 {
-		// If no path found take the current path
-		if wd, err := os.Getwd(); err != nil {
-			locations = []indexer.LocationFS{{
-				FS:   os.DirFS("."),
-				Name: ".",
-				Kind: indexer.Local,
-			}}
-		} else {
-			locations = []indexer.LocationFS{{
-				FS:   os.DirFS(wd),
-				Name: wd,
-				Kind: indexer.Local,
-			}}
-		}
-	} else {
-		locations = verifyLocations(append(args, af.Base...), af.Silent)
+	if len(locations) == 0 {
+		return errors.New("no valid locations to smash :(")
+	}
+
+	a := smash.App{
+		Flags:     af,
+		Args:      args,
+		Locations: locations,
+		Logger:    logger,
 	}
+	return console.Run(command.Context(), &a)
+}
+
+func watchE(command *cobra.Command, args []string) error {
+	locations := smashLocations(args)
 
 	if len(locations) == 0 {
-		return errors.New("no valid locations to smash :(")
+		return errors.New("no valid locations to watch :(")
 	}
 
 	a := smash.App{
 		Flags:     af,
 		Args:      args,
 		Locations: locations,
+		Logger:    logger,
+	}
+	return console.RunWatch(command.Context(), &a)
+}
+
+func serveE(command *cobra.Command, args []string) error {
+	if !af.Silent {
+		console.PrintVersionInfo(false)
+		theme.Println("Serving API on", theme.StyleUrl("http://"+af.ServeAddress+"/scans"), "(Ctrl+C to stop)")
+	}
+	server := smash.NewServer(af)
+	server.Logger = logger
+	return server.ListenAndServe(command.Context(), af.ServeAddress)
+}
+
+// smashLocations Returns the locations given (with --base), or the current directory when none are.
+func smashLocations(args []string) []indexer.LocationFS {
+	var locations []indexer.LocationFS
+
+	if len(args) == 0 {
+		// If no path found take the current path
+		wd, err := os.Getwd()
+		if err != nil {
+			wd = "."
+		}
+		locations = []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, wd, os.DirFS(wd))}
+	} else {
+		locations = verifyLocations(append(args, af.Base...))
+	}
+	return locations
+}
+
+func verifyE(command *cobra.Command, args []string) error {
+	manifestPath := args[0]
+	fs, err := os.Open(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	// sha256sum style manifests don't say which algorithm, it's given or implied by the filename (ie. SHA256SUMS)
+	var prefer []algorithms.Algorithm
+	if command.Flags().Changed("algorithm") {
+		prefer = append(prefer, af.PrimaryAlgorithm())
+	}
+	if algorithm, ok := manifest.AlgorithmFromName(manifestPath); ok {
+		prefer = append(prefer, algorithm)
+	}
+	m, err := manifest.Read(fs, prefer...)
+	if errors.Is(err, manifest.ErrAmbiguousHash) {
+		return fmt.Errorf("failed to read manifest %s: %w, use --algorithm to pick one", manifestPath, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read manifest %s: %w", manifestPath, err)
 	}
-	return a.Run()
+
+	locations := verifyLocations(args[1:])
+	results, err := smash.VerifyManifest(m, manifestPath, locations, af.MaxWorkers)
+	summary := console.PrintVerifyResults(results)
+	if err != nil {
+		return err
+	}
+	return summary.Err()
 }
 
-func verifyLocations(locations []string, silent bool) []indexer.LocationFS {
+func verifyLocations(locations []string) []indexer.LocationFS {
 	var vl []indexer.LocationFS
 	for _, location := range locations {
 		if _, err := os.Stat(location); os.IsNotExist(err) {
-			if !silent {
-				theme.Warn.Println("Ignoring invalid path ", theme.ColourFilename(location))
-			}
+			logger.Warn("ignoring invalid path", "path", location)
 			continue
 		}
 		l := indexer.NewLocationFS(indexer.Local, location, os.DirFS(location))
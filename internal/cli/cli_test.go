@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+func TestExecuteRejectsBadLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		expected string
+	}{
+		{name: "unsupported log format", flag: "--log-format=xml", expected: "unsupported log format"},
+		{name: "unwritable log file", flag: "--log-file=" + filepath.Join(t.TempDir(), "missing", "smash.log"), expected: "failed to open log file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				af.LogFormat, af.LogFile = "", ""
+			})
+			err := execute(context.Background(), []string{tt.flag, "--silent", "--no-output", t.TempDir()})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestSmashLocationsDefaultsToWorkingDirectory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations := smashLocations(nil)
+	if len(locations) != 1 || locations[0].Name != wd || locations[0].Kind != indexer.Local {
+		t.Fatalf("expected the working directory, got %+v", locations)
+	}
+	if device := indexer.DetectDevice(wd); locations[0].Device != device {
+		t.Errorf("expected device %v, got %v", device, locations[0].Device)
+	}
+}
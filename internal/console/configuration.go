@@ -1,16 +1,19 @@
-package smash
+package console
 
 import (
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/dustin/go-humanize"
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/internal/smash"
 	"github.com/thushan/smash/internal/theme"
 	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/manifest"
 )
 
-func (app *App) printConfiguration() {
+// printConfiguration Prints the settings smash is about to run with.
+func printConfiguration(app *smash.App) {
 	var config any
 	f := app.Flags
 	b := theme.StyleBold
@@ -31,25 +34,42 @@ func (app *App) printConfiguration() {
 			threshold = theme.ColourConfig("invalid")
 		}
 
+		if f.AdaptiveSlices {
+			slices += " (adaptive)"
+		}
+
 		config = "(Slices: " + slices + " | Size: " + size + " | Threshold: " + threshold + ")"
 
 		maxThreads := theme.ColourConfig(f.MaxThreads)
 		maxWorkers := theme.ColourConfig(f.MaxWorkers)
 
 		theme.Println(b.Sprint("Concurrency: "), maxWorkers, "workers |", maxThreads, "threads")
+		theme.Println(b.Sprint("Devices:     "), theme.ColourConfig(buildDevices(app.Locations)))
 
 	} else {
 		config = ""
 	}
 
 	theme.Println(b.Sprint("Slicing:     "), theme.ColourConfig(enabledOrDisabled(!f.DisableSlicing)), config)
-	theme.Println(b.Sprint("Algorithm:   "), theme.ColourConfig(algorithms.Algorithm(f.Algorithm)))
+	theme.Println(b.Sprint("Algorithm:   "), theme.ColourConfig(buildAlgorithms(f)))
 	theme.Println(b.Sprint("Locations:   "), theme.ColourConfig(buildLocations(app.Locations)))
 	theme.Println(b.Sprint("Recursive:   "), theme.ColourConfig(enabledOrDisabled(f.Recurse)))
 
 	if !f.HideOutput && f.OutputFile != "" {
 		theme.Println(b.Sprint("Output:      "), theme.ColourConfig(f.OutputFile), "(json)")
 	}
+	if f.ManifestFile != "" {
+		theme.Println(b.Sprint("Manifest:    "), theme.ColourConfig(f.ManifestFile), "("+manifest.Format(f.ManifestFormat).String()+")")
+	}
+	if f.CheckpointFile != "" {
+		theme.Println(b.Sprint("Checkpoint:  "), theme.ColourConfig(f.CheckpointFile), "(every "+f.CheckpointInterval.String()+")")
+	}
+	if f.ResumeFile != "" {
+		theme.Println(b.Sprint("Resume:      "), theme.ColourConfig(f.ResumeFile), "(every "+f.CheckpointInterval.String()+")")
+	}
+	if len(f.Against) > 0 {
+		theme.Println(b.Sprint("Against:     "), theme.ColourConfig(strings.Join(f.Against, ", ")))
+	}
 
 	if len(f.ExcludeDir) > 0 || len(f.ExcludeFile) > 0 {
 		theme.StyleBold.Println("Excluded")
@@ -70,6 +90,30 @@ func buildLocations(locations []indexer.LocationFS) string {
 	return strings.Join(locs, ", ")
 }
 
+func buildAlgorithms(f *smash.Flags) string {
+	algorithm := f.PrimaryAlgorithm().String()
+	digests := f.DigestAlgorithms()
+	if len(digests) == 0 {
+		return algorithm
+	}
+	names := make([]string, len(digests))
+	for i, digest := range digests {
+		names[i] = digest.String()
+	}
+	return algorithm + " (digests: " + strings.Join(names, ", ") + ")"
+}
+func buildDevices(locations []indexer.LocationFS) string {
+	devices := make(map[uint64]bool)
+	rotational := 0
+	for _, location := range locations {
+		if _, seen := devices[location.Device.ID]; !seen && location.Device.Rotational {
+			rotational++
+		}
+		devices[location.Device.ID] = true
+	}
+	return strconv.Itoa(len(devices)) + " (" + strconv.Itoa(rotational) + " rotational)"
+}
+
 func enabledOrDisabled(value bool) string {
 	if value {
 		return "Enabled"
@@ -78,8 +122,9 @@ func enabledOrDisabled(value bool) string {
 	}
 }
 
-func (app *App) setMaxThreads() {
-	maxThreads := app.Flags.MaxThreads
+// setMaxThreads Limits GOMAXPROCS to --max-threads, only the CLI changes it as it's process wide.
+func setMaxThreads(flags *smash.Flags) {
+	maxThreads := flags.MaxThreads
 	if maxThreads < 1 || maxThreads > runtime.NumCPU() {
 		maxThreads = runtime.NumCPU()
 	}
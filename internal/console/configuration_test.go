@@ -1,8 +1,9 @@
-package smash
+package console
 
 import (
 	"testing"
 
+	"github.com/thushan/smash/internal/smash"
 	"github.com/thushan/smash/pkg/indexer"
 )
 
@@ -94,14 +95,22 @@ func TestSetMaxThreads(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			app := &App{
-				Flags: &Flags{
-					MaxThreads: tt.maxThreads,
-				},
-			}
-			app.setMaxThreads()
+			setMaxThreads(&smash.Flags{MaxThreads: tt.maxThreads})
 			// We can't easily test the actual GOMAXPROCS value without affecting the test environment,
 			// so this test just ensures the function doesn't panic
 		})
 	}
 }
+
+func TestBuildDevices(t *testing.T) {
+	locations := []indexer.LocationFS{
+		{Name: "ssd", Device: indexer.Device{ID: 1, Known: true}},
+		{Name: "hdd1", Device: indexer.Device{ID: 2, Known: true, Rotational: true}},
+		{Name: "hdd1-again", Device: indexer.Device{ID: 2, Known: true, Rotational: true}},
+	}
+
+	expected := "2 (1 rotational)"
+	if result := buildDevices(locations); result != expected {
+		t.Errorf("buildDevices() = %v, want %v", result, expected)
+	}
+}
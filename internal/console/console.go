@@ -0,0 +1,54 @@
+package console
+
+import (
+	"context"
+
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/pkg/profiler"
+)
+
+// Run Smashes the app's locations printing the progress & results to the
+// console, then reviews the duplicates with --interactive. Unlike App.Run it
+// changes the process, ie. GOMAXPROCS & pterm's output, so is only for the CLI.
+func Run(ctx context.Context, app *smash.App) error {
+	af := app.Flags
+	output := NewOutputManager(af)
+	closeLog, err := startLogger(app)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	if !af.Silent {
+		PrintVersionInfo(af.ShowVersion)
+		if af.ShowVersion {
+			return nil
+		}
+		printConfiguration(app)
+	}
+
+	if af.Profile {
+		profiler.InitialiseProfiler()
+	}
+	setMaxThreads(af)
+	app.Observers = append([]smash.Observer{newConsoleObserver(app, output, true)}, app.Observers...)
+
+	if err := app.Run(ctx); err != nil || !af.Interactive {
+		return err
+	}
+	return RunReview(app.GenerateReportOutput())
+}
+
+// startLogger Sets up the logger from the flags unless the app was given one,
+// returning a func to close the log file once the run is done.
+func startLogger(app *smash.App) (func() error, error) {
+	if app.Logger != nil {
+		return func() error { return nil }, nil
+	}
+	logger, closer, err := NewLogger(app.Flags)
+	if err != nil {
+		return nil, err
+	}
+	app.Logger = logger
+	return closer, nil
+}
@@ -0,0 +1,145 @@
+package console
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+const (
+	TreeNextChild  = "├─"
+	TreeLastChild  = "└─"
+	TreeMetaIndent = "  "
+)
+
+// PrintRunAnalysis Prints the top duplicates, overlaps, known & empty files of a run.
+func PrintRunAnalysis(app *smash.App, ignoreEmptyFiles bool) {
+	duplicates := app.Session.Dupes
+	emptyFiles := app.Session.Empty.Files
+	topFiles := app.Summary.TopFiles
+
+	totalDuplicates := app.Summary.DuplicateFiles
+
+	theme.StyleHeading.Println("---| Duplicate Files (", totalDuplicates, ")")
+
+	if duplicates.Size() == 0 || len(topFiles) == 0 {
+		theme.Println(theme.ColourSuccess("No duplicates found :-)"))
+	} else {
+
+		if !app.Flags.HideTopList {
+			theme.StyleSubHeading.Println("---[ Top ", app.Flags.ShowTop, " Duplicates ]---")
+			for _, tf := range topFiles {
+				if files, ok := duplicates.Load(tf.Key); ok {
+					displayFiles(files.Files, false)
+				}
+			}
+			if overlaps := app.Summary.Overlaps; len(overlaps) != 0 {
+				theme.StyleSubHeading.Println("---[ Top ", app.Flags.ShowTop, " Directory Overlaps ]---")
+				printDirectoryOverlaps(overlaps)
+			}
+		}
+
+		if app.Flags.ShowDuplicates {
+			theme.StyleSubHeading.Println("---[ All Duplicates ]---")
+			duplicates.Range(func(hash string, files *smash.DuplicateFiles) bool {
+				displayFiles(files.Files, true)
+				return true
+			})
+		}
+	}
+
+	if len(app.Session.Known) != 0 {
+		theme.StyleHeading.Println("---| Known Files (", len(app.Session.Known), ")")
+		printKnownFiles(app.Session.Known)
+	}
+
+	if !ignoreEmptyFiles && len(emptyFiles) != 0 {
+		theme.StyleHeading.Println("---| Empty Files (", len(emptyFiles), ")")
+		printSmashHits(emptyFiles, false)
+	}
+
+}
+
+func displayFiles(files []smash.File, showMeta bool) {
+	duplicateFiles := len(files) - 1
+	if duplicateFiles != 0 {
+		root := files[0]
+		dupes := files[1:]
+		var dupeSize string
+		if len(files) > 2 {
+			// Since len(files) > 2, we know len(files) >= 3
+			// Calculate duplicate count safely
+			duplicateCount := len(files) - 1
+			if duplicateCount > 0 && duplicateCount < len(files) {
+				// #nosec G115 -- duplicateCount is guaranteed positive by the checks above
+				fileCount := uint64(duplicateCount)
+				totalDupeSize := fileCount * root.FileSize
+				dupeSize = "(" + theme.ColourFileSizeDupe(humanize.Bytes(totalDupeSize)) + ")"
+			} else {
+				dupeSize = " "
+			}
+		} else {
+			dupeSize = " "
+		}
+		theme.Println(theme.ColourFilename(root.Path), " ", theme.ColourFileSize(root.FileSizeF), dupeSize, theme.ColourHash(root.Hash))
+		if showMeta {
+			theme.Println(theme.ColourFolderHierarchy(TreeMetaIndent), theme.ColourFileMeta(formatFileMeta(root.Meta)))
+		}
+		printSmashHits(dupes, showMeta)
+	}
+}
+
+func printSmashHits(files []smash.File, showMeta bool) {
+	lastIndex := len(files) - 1
+	for index, file := range files {
+		var subTree string
+		if index < lastIndex {
+			subTree = TreeNextChild
+		} else {
+			subTree = TreeLastChild
+		}
+		if showMeta {
+			theme.Println(theme.ColourFolderHierarchy(subTree), theme.ColourFilenameA(file.Path), " ", theme.ColourFileMeta(formatFileMeta(file.Meta)))
+		} else {
+			theme.Println(theme.ColourFolderHierarchy(subTree), theme.ColourFilenameA(file.Path))
+		}
+	}
+}
+
+// printKnownFiles Prints each matched file with the known file it matched, ie. "photos/a.raw └─ archive.sha256: /archive/a.raw"
+func printKnownFiles(matches []smash.KnownMatch) {
+	for _, match := range matches {
+		theme.Println(theme.ColourFilename(match.File.Path), " ", theme.ColourFileSize(match.File.FileSizeF), theme.ColourHash(match.File.Hash))
+		known := match.Known.Source
+		if match.Known.Path != "" {
+			known += ": " + match.Known.Path
+		}
+		theme.Println(theme.ColourFolderHierarchy(TreeLastChild), theme.ColourFilenameA(known))
+	}
+}
+
+// formatFileMeta Summarises the metadata that helps decide which copy to keep, ie. "2024-01-02 15:04 -rw-r--r-- thushan"
+func formatFileMeta(meta indexer.FileMeta) string {
+	owner := meta.Owner
+	if owner == "" && meta.UID != indexer.UnknownID {
+		owner = strconv.Itoa(meta.UID)
+	}
+	modTime := "-"
+	if !meta.ModTime.IsZero() {
+		modTime = meta.ModTime.Local().Format("2006-01-02 15:04")
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", modTime, meta.Mode, owner))
+}
+
+// printDirectoryOverlaps Prints each pair of directories with the files they share, ie. "photos 1.2 GB (120 files) └─ backup/photos"
+func printDirectoryOverlaps(overlaps []smash.DirectoryOverlap) {
+	for _, overlap := range overlaps {
+		theme.Println(theme.ColourPath(overlap.DirA), " ", theme.ColourFileSize(humanize.Bytes(overlap.Size)), "("+theme.ColourNumber(overlap.Files), "files)")
+		theme.Println(theme.ColourFolderHierarchy(TreeLastChild), theme.ColourFilenameA(overlap.DirB))
+	}
+}
@@ -0,0 +1,48 @@
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+func TestFormatFileMeta(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		expected string
+		meta     indexer.FileMeta
+	}{
+		{
+			name:     "with owner",
+			meta:     indexer.FileMeta{ModTime: modTime, Mode: 0o644, Owner: "thushan", UID: 1000},
+			expected: "2024-01-02 15:04 -rw-r--r-- thushan",
+		},
+		{
+			name:     "unresolved owner falls back to uid",
+			meta:     indexer.FileMeta{ModTime: modTime, Mode: 0o600, UID: 1984},
+			expected: "2024-01-02 15:04 -rw------- 1984",
+		},
+		{
+			name:     "no owners on platform",
+			meta:     indexer.FileMeta{ModTime: modTime, Mode: 0o644, UID: indexer.UnknownID},
+			expected: "2024-01-02 15:04 -rw-r--r--",
+		},
+		{
+			name:     "unknown mod time",
+			meta:     indexer.FileMeta{Mode: 0o644, UID: indexer.UnknownID},
+			expected: "- -rw-r--r--",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := formatFileMeta(tt.meta)
+			if actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+)
+
+// NewLogger Returns the logger for the flags & a func to close --log-file.
+// Structured logs go to --log-file (or stderr) even when silent, skipped files
+// are logged at info & every smashed file at debug with --verbose.
+func NewLogger(flags *smash.Flags) (*slog.Logger, func() error, error) {
+	closer := func() error { return nil }
+	if flags.LogFormat == "" && flags.LogFile == "" {
+		return slog.New(newConsoleHandler(flags)), closer, nil
+	}
+
+	var handler func(io.Writer, *slog.HandlerOptions) slog.Handler
+	switch flags.LogFormat {
+	case smash.LogFormatText, "":
+		handler = func(w io.Writer, options *slog.HandlerOptions) slog.Handler { return slog.NewTextHandler(w, options) }
+	case smash.LogFormatJSON:
+		handler = func(w io.Writer, options *slog.HandlerOptions) slog.Handler { return slog.NewJSONHandler(w, options) }
+	default:
+		return nil, nil, fmt.Errorf("unsupported log format %q, use one of %s", flags.LogFormat, strings.Join(smash.LogFormats, ", "))
+	}
+
+	var w io.Writer = os.Stderr
+	if flags.LogFile != "" {
+		fs, err := os.OpenFile(flags.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = fs
+		closer = fs.Close
+	}
+
+	level := slog.LevelInfo
+	if flags.Verbose {
+		level = slog.LevelDebug
+	}
+	return slog.New(handler(w, &slog.HandlerOptions{Level: level})), closer, nil
+}
+
+// consoleHandler Prints log records with smash's theme like it always has. The
+// summary already covers the rest, so only warnings, errors & (with --verbose)
+// skipped files are printed.
+type consoleHandler struct {
+	attrs   []slog.Attr
+	silent  bool
+	verbose bool
+}
+
+func newConsoleHandler(flags *smash.Flags) *consoleHandler {
+	return &consoleHandler{silent: flags.Silent, verbose: flags.Verbose && !flags.Silent}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.silent {
+		return false
+	}
+	return level >= slog.LevelWarn || (h.verbose && level >= slog.LevelInfo)
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var path string
+	var err any
+	visit := func(a slog.Attr) bool {
+		switch a.Key {
+		case "path":
+			path = a.Value.String()
+		case "error":
+			err = a.Value.Any()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		visit(a)
+	}
+	r.Attrs(visit)
+
+	message := []any{capitalise(r.Message)}
+	if path != "" {
+		message = append(message, theme.ColourFilename(path))
+	}
+	if err != nil {
+		message = append(message, "because", err)
+	}
+
+	switch {
+	case r.Level >= slog.LevelError:
+		theme.Error.Println(message...)
+	case r.Level >= slog.LevelWarn:
+		theme.Warn.Println(message...)
+	case path != "" && err != nil:
+		theme.WarnSkipWithContext(path, err)
+	}
+	return nil
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = append(handler.attrs[:len(handler.attrs):len(handler.attrs)], attrs...)
+	return &handler
+}
+
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func capitalise(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
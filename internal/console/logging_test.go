@@ -0,0 +1,71 @@
+package console
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thushan/smash/internal/smash"
+)
+
+func TestNewLogger(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "smash.log")
+	logger, closeLog, err := NewLogger(&smash.Flags{LogFormat: smash.LogFormatJSON, LogFile: filename, Silent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("smashed file", "path", "/mnt/c/dos/run.exe")
+	logger.Info("skipped file", "path", "/mnt/c/pagefile.sys", "location", "/mnt/c", "kind", smash.ClassifyFail(fs.ErrPermission))
+	if err := closeLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(fs)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("expected a json line, got %q", scanner.Text())
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("expected only the info line without --verbose, got %v", lines)
+	}
+	if lines[0]["path"] != "/mnt/c/pagefile.sys" || lines[0]["location"] != "/mnt/c" || lines[0]["kind"] != "permission" {
+		t.Errorf("expected the skipped file's fields, got %v", lines[0])
+	}
+
+	if _, _, err := NewLogger(&smash.Flags{LogFormat: "syslog"}); err == nil {
+		t.Error("expected an unsupported log format to fail")
+	}
+}
+
+func TestConsoleHandlerEnabled(t *testing.T) {
+	tests := []struct {
+		flags smash.Flags
+		level slog.Level
+		want  bool
+	}{
+		{smash.Flags{}, slog.LevelWarn, true},
+		{smash.Flags{}, slog.LevelInfo, false},
+		{smash.Flags{Verbose: true}, slog.LevelInfo, true},
+		{smash.Flags{Verbose: true}, slog.LevelDebug, false},
+		{smash.Flags{Silent: true}, slog.LevelError, false},
+	}
+	for _, tt := range tests {
+		if got := newConsoleHandler(&tt.flags).Enabled(context.Background(), tt.level); got != tt.want {
+			t.Errorf("expected %v for %s with %+v, got %v", tt.want, tt.level, tt.flags, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package console
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+	"github.com/thushan/smash/pkg/nerdstats"
+)
+
+// consoleObserver Shows a spinner for each stage of a run, then prints the
+// analysis & summary once finished.
+type consoleObserver struct {
+	smash.NopObserver
+	app        *smash.App
+	output     *OutputManager
+	progress   *pterm.MultiPrinter
+	spinners   map[smash.Stage]SpinnerHandle
+	messages   map[smash.Stage]string
+	ticker     chan struct{}
+	startStats nerdstats.NerdStats
+	multi      bool
+	mu         sync.Mutex
+}
+
+// newConsoleObserver Returns the console for the app, multi shows the spinners
+// of every stage together rather than one after another.
+func newConsoleObserver(app *smash.App, output *OutputManager, multi bool) *consoleObserver {
+	return &consoleObserver{
+		app:        app,
+		output:     output,
+		spinners:   make(map[smash.Stage]SpinnerHandle),
+		messages:   make(map[smash.Stage]string),
+		startStats: nerdstats.Snapshot(),
+		multi:      multi,
+	}
+}
+
+func (c *consoleObserver) StageStarted(stage smash.Stage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.multi && c.progress == nil && c.output.ShouldShowProgress() {
+		mp := theme.MultiWriter()
+		c.progress = &mp
+		c.progress.Start()
+	}
+
+	var spinner pterm.SpinnerPrinter
+	var message string
+	switch stage {
+	case smash.StageIndexing:
+		spinner, message = theme.IndexingSpinner(), "Indexing locations..."
+	case smash.StageSmashing:
+		spinner, message = theme.SmashingSpinner(), "Finding duplicates..."
+	case smash.StageRetrying:
+		spinner, message = theme.SmashingSpinner(), fmt.Sprintf("Retrying %d failed files...", c.app.Runtime.Retries.Size())
+	case smash.StageFinalising:
+		spinner, message = theme.FinaliseSpinner(), "Finding smash hits..."
+	}
+	c.messages[stage] = message
+	c.spinners[stage] = c.output.StartSpinner(spinner, message, c.progress)
+
+	if stage == smash.StageSmashing && c.output.ShouldShowProgress() {
+		c.ticker = make(chan struct{})
+		go c.updateDupeCount(c.spinners[stage], c.ticker)
+	}
+}
+
+func (c *consoleObserver) StageFinished(stage smash.Stage, stopped bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	spinner, message := c.spinners[stage], c.messages[stage]
+	if stage == smash.StageSmashing && c.ticker != nil {
+		close(c.ticker)
+		c.ticker = nil
+	}
+	switch {
+	case stopped:
+		spinner.Warning(message + "Stopped!")
+	case stage == smash.StageRetrying && c.app.Runtime.Retries.Size() > 0:
+		spinner.Warning(fmt.Sprintf("%s%d still failing", message, c.app.Runtime.Retries.Size()))
+	default:
+		spinner.Success(message + "Done!")
+	}
+	if stage == smash.StageFinalising && c.progress != nil {
+		_, _ = c.progress.Stop()
+		c.progress = nil
+	}
+}
+
+func (c *consoleObserver) LocationIndexing(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if spinner, ok := c.spinners[smash.StageIndexing]; ok {
+		spinner.UpdateText("Indexing location: " + name)
+	}
+}
+
+// updateDupeCount Shows how many files have been smashed every --progress-update seconds until stopped.
+func (c *consoleObserver) updateDupeCount(pss SpinnerHandle, stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(c.app.Flags.ProgressUpdate) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pss.UpdateText(fmt.Sprintf("Finding duplicates... (%s files smash'd)", pterm.Gray(c.app.Progress.Files.Value())))
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *consoleObserver) ScanFinished(summary *smash.RunSummary) {
+	if c.output.IsSilent() {
+		return
+	}
+	reportStats := nerdstats.Snapshot()
+	PrintRunAnalysis(c.app, c.app.Flags.IgnoreEmpty)
+	analysisStats := nerdstats.Snapshot()
+
+	PrintRunSummary(*summary, c.app.Flags)
+
+	if c.app.Flags.ShowNerdStats {
+		theme.StyleHeading.Println("---| Nerd Stats")
+		PrintNerdStats(c.startStats, "> Initial")
+		PrintNerdStats(reportStats, "> Post-Report")
+		PrintNerdStats(analysisStats, "> Post-Analysis")
+		PrintNerdStats(nerdstats.Snapshot(), "> Post-Summary")
+	}
+}
@@ -1,9 +1,10 @@
-package smash
+package console
 
 import (
 	"sync"
 
 	"github.com/pterm/pterm"
+	"github.com/thushan/smash/internal/smash"
 )
 
 var (
@@ -27,7 +28,7 @@ type OutputManager struct {
 }
 
 // NewOutputManager creates a new output manager based on flags and environment
-func NewOutputManager(flags *Flags) *OutputManager {
+func NewOutputManager(flags *smash.Flags) *OutputManager {
 	env := DetectEnvironment()
 
 	config := OutputConfig{
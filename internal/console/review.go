@@ -0,0 +1,191 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pterm/pterm"
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+)
+
+const (
+	reviewApply  = "Apply marked files"
+	reviewMark   = "Mark a directory"
+	reviewQuit   = "Quit without changing anything"
+	reviewBack   = "Back to groups"
+	reviewHeight = 15
+)
+
+// RunReview Browses the duplicates of a report in the terminal, largest
+// reclaimable groups first, marking files to keep, delete or link then
+// applying them once confirmed. Nothing changes until then.
+func RunReview(report smash.ReportOutput) error {
+	if !DetectEnvironment().IsTerminal {
+		return errors.New("reviewing duplicates needs an interactive terminal")
+	}
+	review := smash.NewReview(report)
+	if len(review.Groups) == 0 {
+		theme.Println(theme.ColourSuccess("No duplicates found :-)"))
+		return nil
+	}
+
+	for {
+		options := []string{reviewApply, reviewMark, reviewQuit}
+		for i, group := range review.Groups {
+			options = append(options, formatReviewGroup(i, group))
+		}
+		choice, err := pterm.DefaultInteractiveSelect.
+			WithOptions(options).
+			WithMaxHeight(reviewHeight).
+			Show(fmt.Sprintf("%d duplicate groups, type to filter", len(review.Groups)))
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case reviewQuit:
+			return nil
+		case reviewMark:
+			if err := promptMarkDirectory(review); err != nil {
+				return err
+			}
+		case reviewApply:
+			if applied, err := promptApply(review); err != nil || applied {
+				return err
+			}
+		default:
+			index, _ := strconv.Atoi(strings.TrimPrefix(strings.Fields(choice)[0], "#"))
+			if err := promptGroup(review.Groups[index-1]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// formatReviewGroup Summarises a group for the list, ie. "#1 1.2 GB reclaimable, 3 × 600 MB video.mp4 [1 marked]"
+func formatReviewGroup(index int, group *smash.ReviewGroup) string {
+	label := fmt.Sprintf("#%d %s reclaimable, %d × %s %s",
+		index+1, humanize.Bytes(group.Reclaimable()), len(group.Files), humanize.Bytes(group.Size), group.Files[0].Filename)
+	if marked := group.Marked(); marked > 0 {
+		label += fmt.Sprintf(" [%d marked]", marked)
+	}
+	return label
+}
+
+// formatReviewFile Describes a file in a group with its action & the metadata that helps decide which to keep.
+func formatReviewFile(index int, action smash.ReviewAction, file smash.ReportFileSummary) string {
+	owner := file.Owner
+	if owner == "" {
+		owner = strconv.Itoa(file.UID)
+	}
+	modTime := "-"
+	if !file.ModTime.IsZero() {
+		modTime = file.ModTime.Local().Format("2006-01-02 15:04")
+	}
+	label := fmt.Sprintf("%d. [%-6s] %s  %s %s %s", index+1, action, file.Path, modTime, file.Mode, owner)
+	if file.Links > 1 {
+		label += fmt.Sprintf(" (%d links)", file.Links)
+	}
+	return label
+}
+
+func promptGroup(group *smash.ReviewGroup) error {
+	for {
+		theme.StyleHeading.Println("---| ", group.Files[0].Filename, " (", humanize.Bytes(group.Size), group.ContentType, ")")
+		theme.Println(theme.ColourFolderHierarchy(TreeLastChild), theme.ColourHash(group.Hash))
+
+		options := []string{reviewBack}
+		for i, file := range group.Files {
+			options = append(options, formatReviewFile(i, group.Actions[i], file))
+		}
+		choice, err := pterm.DefaultInteractiveSelect.
+			WithOptions(options).
+			WithMaxHeight(reviewHeight).
+			Show("Pick a file to mark")
+		if err != nil || choice == reviewBack {
+			return err
+		}
+
+		index, _ := strconv.Atoi(strings.TrimSuffix(strings.Fields(choice)[0], "."))
+		action, err := promptAction("Mark " + group.Files[index-1].Path + " to")
+		if err != nil {
+			return err
+		}
+		group.Actions[index-1] = action
+	}
+}
+
+func promptAction(text string) (smash.ReviewAction, error) {
+	options := make([]string, len(smash.ReviewActions))
+	for i, action := range smash.ReviewActions {
+		options[i] = string(action)
+	}
+	choice, err := pterm.DefaultInteractiveSelect.WithOptions(options).Show(text)
+	return smash.ReviewAction(choice), err
+}
+
+func promptMarkDirectory(review *smash.Review) error {
+	dir, err := pterm.DefaultInteractiveTextInput.Show("Directory (every duplicate in or below it is marked)")
+	if err != nil || strings.TrimSpace(dir) == "" {
+		return err
+	}
+	dir = strings.TrimSpace(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	action, err := promptAction("Mark duplicates in " + dir + " to")
+	if err != nil {
+		return err
+	}
+	marked := review.Mark(dir, action)
+	theme.Println("Marked", theme.ColourNumber(marked), "files in", theme.ColourPath(dir), "to", action)
+	return nil
+}
+
+// promptApply Shows what the review will change and applies it once confirmed,
+// returning true if it was applied.
+func promptApply(review *smash.Review) (bool, error) {
+	plan, err := review.Plan()
+	if err != nil {
+		theme.Error.Println(err)
+		return false, nil
+	}
+	if len(plan.Steps) == 0 {
+		theme.Warn.Println("Nothing is marked to delete or link yet")
+		return false, nil
+	}
+
+	theme.StyleHeading.Println("---| Review Plan")
+	for _, step := range plan.Steps {
+		theme.Println(theme.ColourError(step.Action), theme.ColourFilename(step.File.Path))
+		if step.Action == smash.ReviewLink {
+			theme.Println(theme.ColourFolderHierarchy(TreeLastChild), theme.ColourFilenameA(step.Keep.Path))
+		}
+	}
+	theme.Println(writeCategory("Delete:"), theme.ColourNumber(plan.Count(smash.ReviewDelete)), "files")
+	theme.Println(writeCategory("Link:"), theme.ColourNumber(plan.Count(smash.ReviewLink)), "files")
+	theme.Println(writeCategory("Space Reclaimable:"), theme.ColourFileSizeA(humanize.Bytes(plan.Reclaimable)), "(approx)")
+
+	confirmed, err := pterm.DefaultInteractiveConfirm.Show("Apply? Deleted files can't be recovered")
+	if err != nil || !confirmed {
+		return false, err
+	}
+
+	failed := 0
+	for _, result := range plan.Apply() {
+		if result.Err != nil {
+			failed++
+			theme.WarnSkipWithContext(result.Step.File.Path, result.Err)
+		}
+	}
+	theme.Println(writeCategory("Applied:"), theme.ColourNumber(len(plan.Steps)-failed), "files")
+	if failed > 0 {
+		theme.Println(writeCategory("Left Alone:"), theme.ColourError(failed), "files")
+	}
+	return true, nil
+}
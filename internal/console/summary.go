@@ -0,0 +1,100 @@
+package console
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+)
+
+// PrintRunSummary Prints the totals of a run & where its report and manifest were written.
+func PrintRunSummary(rs smash.RunSummary, flags *smash.Flags) {
+	theme.StyleHeading.Println("---| Analysis Summary")
+
+	if rs.Partial {
+		theme.Println(writeCategory("Partial:"), theme.ColourError("stopped early"), "(only covers files smashed before then)")
+		if flags.CheckpointFile != "" || flags.ResumeFile != "" {
+			theme.Println(writeCategory("Resume With:"), theme.ColourConfig("--resume="+flags.CheckpointFile+flags.ResumeFile))
+		}
+	}
+	theme.Println(writeCategory("Total Time:"), theme.ColourTime(calcTotalTime(rs.ElapsedTime)))
+	theme.Println(writeCategory("Total Analysed:"), theme.ColourNumber(rs.TotalFiles))
+	theme.Println(writeCategory("Total Unique:"), theme.ColourNumber(rs.UniqueFiles), "(excludes empty files)")
+	if rs.TotalFileErrors > 0 {
+		theme.Println(writeCategory("Total Skipped:"), theme.ColourError(rs.TotalFileErrors), "("+formatCounts(rs.FailCodes)+")")
+	}
+	if len(rs.Skipped) > 0 {
+		total := int64(0)
+		for _, count := range rs.Skipped {
+			total += count
+		}
+		theme.Println(writeCategory("Total Ignored:"), theme.ColourNumber(total), "("+formatCounts(rs.Skipped)+")")
+	}
+	theme.Println(writeCategory("Total Duplicates:"), theme.ColourNumber(rs.DuplicateFiles))
+	if !flags.IgnoreEmpty && rs.EmptyFiles > 0 {
+		theme.Println(writeCategory("Total Empty Files:"), theme.ColourNumber(rs.EmptyFiles))
+	}
+	if len(flags.Against) > 0 {
+		theme.Println(writeCategory("Total Known:"), theme.ColourNumber(rs.KnownFiles), "(matched --against)")
+		if rs.KnownSliced > 0 {
+			theme.Println(writeCategory(""), theme.ColourError(rs.KnownSliced), "sliced files weren't compared, use --disable-slicing to include them")
+		}
+	}
+	if rs.DuplicateFileSize > 0 {
+		theme.Println(writeCategory("Space Reclaimable:"), theme.ColourFileSizeA(rs.DuplicateFileSizeF), "(approx)")
+	}
+	if !flags.HideOutput && rs.ReportFilename != "" {
+		filename := filepath.Clean(rs.ReportFilename)
+		reportUri := theme.Hyperlink("file://"+filename, filename)
+		theme.Println(writeCategory("Analysis Report:"), theme.StyleUrl(reportUri), "(json)")
+	}
+	if rs.Manifest.Filename != "" {
+		filename := filepath.Clean(rs.Manifest.Filename)
+		manifestUri := theme.Hyperlink("file://"+filename, filename)
+		theme.Println(writeCategory("Manifest:"), theme.StyleUrl(manifestUri), "(", theme.ColourNumber(rs.Manifest.Entries), "files )")
+		if rs.Manifest.Sliced > 0 {
+			theme.Println(writeCategory(""), theme.ColourError(rs.Manifest.Sliced), "sliced files left out, use --disable-slicing to include them")
+		}
+		if rs.Manifest.Skipped > 0 {
+			theme.Println(writeCategory(""), theme.ColourError(rs.Manifest.Skipped), "files left out, their names have line breaks the format can't hold")
+		}
+	}
+}
+func calcTotalTime(elapsedNs int64) string {
+	duration := time.Duration(elapsedNs)
+	switch {
+	case duration >= 60*time.Minute:
+		return duration.Round(time.Minute).String()
+	case duration >= 1*time.Minute:
+		return duration.Round(time.Second).String()
+	case duration <= 1*time.Second:
+		return duration.Round(time.Millisecond).String()
+	default:
+		return duration.Round(time.Second).String()
+	}
+}
+
+func writeCategory(category string) string {
+	return fmt.Sprintf("%20s", category)
+}
+
+// formatCounts Returns the counts as "312 permission, 2 io", largest first.
+func formatCounts[K ~string](counts map[K]int64) string {
+	codes := slices.SortedFunc(maps.Keys(counts), func(a, b K) int {
+		if counts[a] != counts[b] {
+			return cmp.Compare(counts[b], counts[a])
+		}
+		return cmp.Compare(a, b)
+	})
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = fmt.Sprintf("%d %s", counts[code], code)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,8 +1,10 @@
-package smash
+package console
 
 import (
 	"strings"
 	"testing"
+
+	"github.com/thushan/smash/internal/smash"
 )
 
 func TestCalcTotalTime(t *testing.T) {
@@ -24,3 +26,10 @@ func TestCalcTotalTime(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatCounts(t *testing.T) {
+	counts := map[smash.FailCode]int64{smash.FailIO: 2, smash.FailPermission: 312, smash.FailBusy: 2}
+	if got := formatCounts(counts); got != "312 permission, 2 busy, 2 io" {
+		t.Errorf("expected 312 permission, 2 busy, 2 io, got %q", got)
+	}
+}
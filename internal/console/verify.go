@@ -0,0 +1,39 @@
+package console
+
+import (
+	"fmt"
+
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+)
+
+// PrintVerifyResults Prints every file that didn't verify, followed by a summary.
+func PrintVerifyResults(results []smash.VerifyResult) smash.VerifySummary {
+	for _, result := range results {
+		if result.Status == smash.VerifyOK {
+			continue
+		}
+		status := fmt.Sprintf("%-8s", result.Status)
+		if result.Status == smash.VerifyExtra {
+			status = theme.ColourConfigA(status)
+		} else {
+			status = theme.ColourError(status)
+		}
+		if result.Err != nil {
+			theme.Println(status, theme.ColourFilenameA(result.Path), theme.ColourHash("("+result.Err.Error()+")"))
+		} else {
+			theme.Println(status, theme.ColourFilenameA(result.Path))
+		}
+	}
+
+	summary := smash.SummariseVerify(results)
+	theme.StyleHeading.Println("---| Verify Summary")
+	theme.Println(writeCategory("OK:"), theme.ColourNumber(summary.OK))
+	theme.Println(writeCategory("Changed:"), theme.ColourNumber(summary.Changed))
+	theme.Println(writeCategory("Missing:"), theme.ColourNumber(summary.Missing))
+	if summary.Failed > 0 {
+		theme.Println(writeCategory("Failed:"), theme.ColourError(summary.Failed))
+	}
+	theme.Println(writeCategory("Extra:"), theme.ColourNumber(summary.Extra))
+	return summary
+}
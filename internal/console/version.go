@@ -0,0 +1,31 @@
+package console
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+)
+
+// PrintVersionInfo Prints the splash, with where & how smash was built when extended.
+func PrintVersionInfo(extendedInfo bool) {
+	githubUri := theme.Hyperlink(smash.GithubHomeUri, smash.GithubHomeText)
+	latestUri := theme.Hyperlink(smash.GithubLatestUri, smash.Version)
+	padLatest := fmt.Sprintf("%*s", 17-len(smash.Version), "")
+
+	log.Println(theme.ColourSplash(`╔───────────────────────────────────────────────╗
+│  ███████╗███╗   ███╗ █████╗ ███████╗██╗  ██╗  │
+│  ██╔════╝████╗ ████║██╔══██╗██╔════╝██║  ██║  │
+│  ███████╗██╔████╔██║███████║███████╗███████║  │
+│  ╚════██║██║╚██╔╝██║██╔══██║╚════██║██╔══██║  │
+│  ███████║██║ ╚═╝ ██║██║  ██║███████║██║  ██║  │
+│  ╚══════╝╚═╝     ╚═╝╚═╝  ╚═╝╚══════╝╚═╝  ╚═╝  │`))
+	log.Println(theme.ColourSplash("│ "), theme.StyleUrl(githubUri), padLatest, theme.ColourVersion(latestUri), theme.ColourSplash(" │"))
+	log.Println(theme.ColourSplash(`╚───────────────────────────────────────────────╝`))
+	if extendedInfo {
+		log.Println(" smash.Commit:", theme.ColourVersionMeta(smash.Commit))
+		log.Println("  Built:", theme.ColourVersionMeta(smash.Date))
+		log.Println("  Using:", theme.ColourVersionMeta(smash.User))
+	}
+}
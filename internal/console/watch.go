@@ -0,0 +1,91 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/internal/theme"
+	"github.com/thushan/smash/pkg/profiler"
+)
+
+// RunWatch Scans the locations, then prints duplicates as files are created or
+// modified until the context is cancelled (or --timeout), finishing with the usual summary.
+func RunWatch(ctx context.Context, app *smash.App) error {
+	af := app.Flags
+	closeLog, err := startLogger(app)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	if !af.Silent {
+		PrintVersionInfo(false)
+		printConfiguration(app)
+	}
+	if af.Profile {
+		profiler.InitialiseProfiler()
+	}
+	setMaxThreads(af)
+	app.Observers = append([]smash.Observer{newConsoleObserver(app, NewOutputManager(af), false)}, app.Observers...)
+	if err := app.Initialise(); err != nil {
+		return err
+	}
+	if af.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, af.Timeout)
+		defer cancel()
+	}
+
+	events := make(chan smash.WatchEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range events {
+			printWatchEvent(app, event)
+		}
+	}()
+
+	totalFiles, err := app.Watch(ctx, events)
+	close(events)
+	<-done
+	if err != nil {
+		return err
+	}
+
+	app.Finalise(totalFiles)
+	return nil
+}
+
+// printWatchEvent Prints an event of a watch, or writes it as a json line with --json.
+func printWatchEvent(app *smash.App, event smash.WatchEvent) {
+	if app.Flags.WatchJSON {
+		_ = json.NewEncoder(os.Stdout).Encode(event)
+		return
+	}
+	if app.Flags.Silent {
+		return
+	}
+	switch event.Kind {
+	case smash.WatchReady:
+		theme.StyleHeading.Println("---| Watching (", len(app.Locations), " locations )")
+		theme.Println(writeCategory("Indexed:"), theme.ColourNumber(event.Files), "files,", theme.ColourNumber(event.DuplicateFiles), "duplicates already")
+		theme.Println(writeCategory(""), "Press Ctrl+C to stop & summarise")
+	case smash.WatchDuplicate:
+		theme.Println(theme.ColourTime(event.Time.Format(time.TimeOnly)), theme.ColourFilename(event.Path), " ", theme.ColourHash(event.Hash))
+		printDuplicatePaths(event.Duplicates)
+	}
+}
+
+func printDuplicatePaths(paths []string) {
+	lastIndex := len(paths) - 1
+	for index, path := range paths {
+		subTree := TreeNextChild
+		if index == lastIndex {
+			subTree = TreeLastChild
+		}
+		theme.Println(theme.ColourFolderHierarchy(subTree), theme.ColourFilenameA(path))
+	}
+}
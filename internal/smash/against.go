@@ -0,0 +1,233 @@
+package smash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/manifest"
+)
+
+// KnownFile is where a hash given to --against came from.
+type KnownFile struct {
+	Source string
+	Path   string
+	Size   int64
+}
+
+// KnownHashes are the digests loaded from --against, the preloaded side of the
+// map scanned files are matched against.
+type KnownHashes struct {
+	digests map[algorithms.Algorithm]map[string]KnownFile
+}
+
+// KnownMatch is a scanned file with the same digest as a known file.
+type KnownMatch struct {
+	Known KnownFile
+	File  File
+}
+
+// LoadKnownHashes Reads the checksum manifests (any format smash verify reads)
+// or previous smash reports given to --against. Hashes in sha256sum style manifests
+// & bare hash lists that more than one algorithm could produce are read as the
+// first of prefer (the scan's algorithms) that does.
+func LoadKnownHashes(sources []string, prefer ...algorithms.Algorithm) (*KnownHashes, error) {
+	kh := &KnownHashes{digests: make(map[algorithms.Algorithm]map[string]KnownFile)}
+	for _, source := range sources {
+		if err := kh.load(source, prefer); err != nil {
+			return nil, fmt.Errorf("failed to load known hashes from %s: %w", source, err)
+		}
+	}
+	return kh, nil
+}
+
+func (kh *KnownHashes) load(source string, prefer []algorithms.Algorithm) error {
+	fs, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	r := bufio.NewReader(fs)
+	if isJSON(r) {
+		return kh.loadReport(source, r)
+	}
+	if algorithm, ok := manifest.AlgorithmFromName(source); ok {
+		prefer = append([]algorithms.Algorithm{algorithm}, prefer...)
+	}
+	m, err := manifest.Read(r, prefer...)
+	if err != nil {
+		return err
+	}
+	for _, entry := range m.Entries {
+		for algorithm, digest := range entry.Digests {
+			kh.add(algorithm, digest, KnownFile{Source: source, Path: entry.Path, Size: entry.Size})
+		}
+	}
+	return nil
+}
+
+// loadReport Loads the fully hashed files of a smash report, a sliced hash
+// depends on how the file was sliced so only full hashes can be compared.
+func (kh *KnownHashes) loadReport(source string, r io.Reader) error {
+	var report ReportOutput
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return fmt.Errorf("invalid report: %w", err)
+	}
+	if report.Meta.Config == nil {
+		return errors.New("report doesn't record the algorithm it was hashed with")
+	}
+	primary := report.Meta.Config.PrimaryAlgorithm()
+
+	var files []ReportFileSummary
+	for _, dupe := range report.Analysis.Dupes {
+		files = append(files, dupe.Files...)
+	}
+	for _, known := range report.Analysis.Known {
+		files = append(files, known.ReportFileSummary)
+	}
+	for _, file := range files {
+		if !file.FullHash {
+			continue
+		}
+		// #nosec G115 -- file sizes are well within int64
+		known := KnownFile{Source: source, Path: file.Path, Size: int64(file.Size)}
+		kh.add(primary, file.Hash, known)
+		for name, digest := range file.Digests {
+			if algorithm, ok := algorithms.Parse(name); ok {
+				kh.add(algorithm, digest, known)
+			}
+		}
+	}
+	return nil
+}
+
+func (kh *KnownHashes) add(algorithm algorithms.Algorithm, digest string, known KnownFile) {
+	digests, ok := kh.digests[algorithm]
+	if !ok {
+		digests = make(map[string]KnownFile)
+		kh.digests[algorithm] = digests
+	}
+	digest = strings.ToLower(digest)
+	if _, exists := digests[digest]; !exists {
+		digests[digest] = known
+	}
+}
+
+// Algorithms Returns the algorithms there are known digests for.
+func (kh *KnownHashes) Algorithms() []algorithms.Algorithm {
+	known := make([]algorithms.Algorithm, 0, len(kh.digests))
+	for algorithm := range kh.digests {
+		known = append(known, algorithm)
+	}
+	sort.Slice(known, func(i, j int) bool {
+		return known[i] < known[j]
+	})
+	return known
+}
+
+// Size Returns the number of known digests across all algorithms.
+func (kh *KnownHashes) Size() int {
+	size := 0
+	for _, digests := range kh.digests {
+		size += len(digests)
+	}
+	return size
+}
+
+// Match Returns the known file sharing a digest with the file, only fully
+// hashed files are compared since sliced hashes won't match anything else.
+func (kh *KnownHashes) Match(file File, primary algorithms.Algorithm) (KnownFile, bool) {
+	if !file.FullHash || file.EmptyFile {
+		return KnownFile{}, false
+	}
+	if known, ok := kh.lookup(primary, file.Hash, file.FileSize); ok {
+		return known, true
+	}
+	for name, digest := range file.Digests {
+		if algorithm, ok := algorithms.Parse(name); ok {
+			if known, ok := kh.lookup(algorithm, digest, file.FileSize); ok {
+				return known, true
+			}
+		}
+	}
+	return KnownFile{}, false
+}
+
+func (kh *KnownHashes) lookup(algorithm algorithms.Algorithm, digest string, size uint64) (KnownFile, bool) {
+	known, ok := kh.digests[algorithm][digest]
+	// #nosec G115 -- file sizes are well within int64
+	if !ok || (known.Size != manifest.UnknownSize && known.Size != int64(size)) {
+		return KnownFile{}, false
+	}
+	return known, true
+}
+
+// checkAlgorithms Returns an error when none of the algorithms being used have known digests, nothing could ever match.
+func (kh *KnownHashes) checkAlgorithms(used []algorithms.Algorithm) error {
+	for _, algorithm := range used {
+		if _, ok := kh.digests[algorithm]; ok {
+			return nil
+		}
+	}
+	known := kh.Algorithms()
+	if len(known) == 0 {
+		return errors.New("no known hashes were found in --against")
+	}
+	names := make([]string, len(known))
+	for i, algorithm := range known {
+		names[i] = algorithm.String()
+	}
+	return fmt.Errorf("--against only has %s digests, add one of them to --algorithm", strings.Join(names, ", "))
+}
+
+// matchKnown Matches every scanned file against --against, it must run before
+// the run summary prunes unique files from the session.
+func (app *App) matchKnown() {
+	known := app.Runtime.Known
+	if known == nil {
+		return
+	}
+	primary := app.Flags.PrimaryAlgorithm()
+	var matches []KnownMatch
+	sliced := int64(0)
+
+	app.Session.Dupes.Range(func(hash string, dupes *DuplicateFiles) bool {
+		for _, file := range dupes.Files {
+			if !file.FullHash {
+				sliced++
+				continue
+			}
+			if match, ok := known.Match(file, primary); ok {
+				matches = append(matches, KnownMatch{File: file, Known: match})
+			}
+		}
+		return true
+	})
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].File.Location+matches[i].File.Path < matches[j].File.Location+matches[j].File.Path
+	})
+	app.Session.Known = matches
+	app.Session.KnownSliced = sliced
+}
+
+func isJSON(r *bufio.Reader) bool {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return false
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0] == '{'
+		}
+		_, _ = r.ReadByte()
+	}
+}
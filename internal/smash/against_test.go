@@ -0,0 +1,120 @@
+package smash
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/manifest"
+)
+
+const (
+	sha256Known = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	md5Known    = "098f6bcd4621d373cade4e832627b4f6"
+)
+
+func writeKnownSource(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadKnownHashesFromManifest(t *testing.T) {
+	source := writeKnownSource(t, "archive.txt", "SHA256 (/archive/DSC19841.ARW) = "+sha256Known+"\nMD5 (/archive/DSC19841.ARW) = "+md5Known+"\n")
+
+	kh, err := LoadKnownHashes([]string{source})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if kh.Size() != 2 {
+		t.Errorf("expected 2 known digests, got %d", kh.Size())
+	}
+
+	file := File{Hash: md5Known, FileSize: 4, FullHash: true}
+	known, ok := kh.Match(file, algorithms.Md5)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if known.Source != source || known.Path != "/archive/DSC19841.ARW" {
+		t.Errorf("expected %s from %s, got %+v", "/archive/DSC19841.ARW", source, known)
+	}
+}
+
+func TestLoadKnownHashesFromReport(t *testing.T) {
+	report := ReportOutput{
+		Meta: ReportMeta{Config: &Flags{Algorithms: []int{int(algorithms.Sha256)}}},
+		Analysis: ReportFiles{Dupes: []ReportDuplicateSummary{{
+			Hash: sha256Known,
+			Files: []ReportFileSummary{
+				{ReportFileBaseSummary: ReportFileBaseSummary{Path: "/archive/a.ARW"}, Hash: sha256Known, Size: 4, FullHash: true, Digests: map[string]string{"md5": md5Known}},
+				{ReportFileBaseSummary: ReportFileBaseSummary{Path: "/archive/sliced.ARW"}, Hash: "abcd", Size: 4},
+			},
+		}}},
+	}
+	content, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := writeKnownSource(t, "report.json", "\n"+string(content))
+
+	kh, err := LoadKnownHashes([]string{source})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		file    File
+		primary algorithms.Algorithm
+		matches bool
+	}{
+		{name: "primary hash", file: File{Hash: sha256Known, FileSize: 4, FullHash: true}, primary: algorithms.Sha256, matches: true},
+		{name: "additional digest", file: File{Hash: "ffff", FileSize: 4, FullHash: true, Digests: map[string]string{"md5": md5Known}}, primary: algorithms.Xxhash, matches: true},
+		{name: "different size", file: File{Hash: sha256Known, FileSize: 5, FullHash: true}, primary: algorithms.Sha256},
+		{name: "sliced", file: File{Hash: sha256Known, FileSize: 4}, primary: algorithms.Sha256},
+		{name: "sliced in report", file: File{Hash: "abcd", FileSize: 4, FullHash: true}, primary: algorithms.Sha256},
+		{name: "different algorithm", file: File{Hash: sha256Known, FileSize: 4, FullHash: true}, primary: algorithms.Blake3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := kh.Match(tt.file, tt.primary); ok != tt.matches {
+				t.Errorf("expected match %v, got %v", tt.matches, ok)
+			}
+		})
+	}
+}
+
+func TestKnownHashesCheckAlgorithms(t *testing.T) {
+	source := writeKnownSource(t, "known-bad.txt", sha256Known+"\n")
+	if _, err := LoadKnownHashes([]string{source}); !errors.Is(err, manifest.ErrAmbiguousHash) {
+		t.Errorf("expected %v, got %v", manifest.ErrAmbiguousHash, err)
+	}
+	kh, err := LoadKnownHashes([]string{source}, algorithms.Xxhash, algorithms.Sha256)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := kh.checkAlgorithms([]algorithms.Algorithm{algorithms.Xxhash, algorithms.Sha256}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := kh.checkAlgorithms([]algorithms.Algorithm{algorithms.Xxhash}); err == nil {
+		t.Error("expected an error when no algorithm has known digests")
+	}
+}
+
+func TestLoadKnownHashesAlgorithmFromName(t *testing.T) {
+	source := writeKnownSource(t, "archive.sha256", sha256Known+"  /archive/DSC19841.ARW\n")
+	kh, err := LoadKnownHashes([]string{source}, algorithms.Blake3)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := kh.checkAlgorithms([]algorithms.Algorithm{algorithms.Sha256}); err != nil {
+		t.Errorf("expected the filename to pick sha-256, got %v", err)
+	}
+}
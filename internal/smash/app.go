@@ -1,66 +1,127 @@
 package smash
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/thushan/smash/pkg/nerdstats"
-	"github.com/thushan/smash/pkg/profiler"
+	"github.com/thushan/smash/pkg/algorithms"
 
 	"github.com/puzpuzpuz/xsync/v4"
 
-	"github.com/pterm/pterm"
-	"github.com/thushan/smash/internal/theme"
-
-	"github.com/thushan/smash/internal/algorithms"
 	"github.com/thushan/smash/pkg/slicer"
 
 	"github.com/thushan/smash/pkg/indexer"
 )
 
 type App struct {
-	Flags     *Flags
-	Session   *AppSession
-	Runtime   *AppRuntime
-	Summary   *RunSummary
-	Output    *OutputManager
+	Flags    *Flags
+	Session  *AppSession
+	Runtime  *AppRuntime
+	Summary  *RunSummary
+	Progress *AppProgress
+	Metrics  *Metrics
+	Logger   *slog.Logger
+	// Observers are told about the run as it happens, the console & report are put first
+	Observers []Observer
 	Args      []string
 	Locations []indexer.LocationFS
 }
+
+// AppProgress is updated as files are smashed so a running app can be polled.
+type AppProgress struct {
+	Files    *xsync.Counter
+	Fails    *xsync.Counter
+	location atomic.Pointer[string]
+}
 type AppSession struct {
-	Dupes     *xsync.Map[string, *DuplicateFiles]
-	Fails     *xsync.Map[string, error]
-	Empty     *EmptyFiles
-	StartTime int64
-	EndTime   int64
+	Dupes       *xsync.Map[string, *DuplicateFiles]
+	Fails       *xsync.Map[string, error]
+	Empty       *EmptyFiles
+	Skipped     *SkippedFiles
+	Known       []KnownMatch
+	KnownSliced int64
+	StartTime   int64
+	EndTime     int64
+	Partial     bool
 }
 type AppRuntime struct {
-	Slicer        *slicer.Slicer
-	SlicerOptions *slicer.Options
-	IndexerConfig *indexer.IndexerConfig
-	Files         chan *indexer.FileFS
+	Known          *KnownHashes
+	Checkpoint     *Checkpoint
+	Resumed        *xsync.Map[string, File]
+	Retries        *xsync.Map[string, *indexer.FileFS]
+	Slicer         *slicer.Slicer
+	SlicerOptions  *slicer.Options
+	IndexerConfig  *indexer.IndexerConfig
+	LocationLimits LocationLimits
+	Queues         []*DeviceQueue
 }
 
 const ReportOutputTemplate = "report-*.json"
 
-func (app *App) Run() error {
+func NewAppProgress() *AppProgress {
+	return &AppProgress{
+		Files: xsync.NewCounter(),
+		Fails: xsync.NewCounter(),
+	}
+}
 
-	af := app.Flags
+// Location Returns the location currently being indexed.
+func (p *AppProgress) Location() string {
+	if location := p.location.Load(); location != nil {
+		return *location
+	}
+	return ""
+}
+func (p *AppProgress) setLocation(location string) {
+	p.location.Store(&location)
+}
 
-	// Initialize output manager first
-	app.Output = NewOutputManager(af)
+// Run Smashes the locations, cancelling the context (or --timeout) stops early
+// with a partial report of what was smashed by then. Nothing is printed & no
+// process-wide state is changed, the console is an observer (see internal/console).
+func (app *App) Run(ctx context.Context) error {
+	if err := app.Initialise(); err != nil {
+		return err
+	}
+	return app.Exec(ctx)
+}
 
-	if !af.Silent {
-		PrintVersionInfo(af.ShowVersion)
-		if af.ShowVersion {
-			return nil
-		}
-		app.printConfiguration()
+// startMetrics Records Prometheus metrics for --metrics-addr & --metrics-push, serving them on --metrics-addr
+// unless the app was given metrics to record to. The returned func stops serving them once the run is done.
+func (app *App) startMetrics() (func(), error) {
+	af := app.Flags
+	if (af.MetricsAddress == "" && af.MetricsPush == "") || app.Metrics != nil {
+		return func() {}, nil
+	}
+	app.Metrics = NewMetrics()
+	if af.MetricsAddress == "" {
+		return func() {}, nil
+	}
+	stop, err := app.Metrics.Serve(app.Flags.MetricsAddress, app.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve metrics: %w", err)
+	}
+	return stop, nil
+}
+
+// Initialise Validates the flags then sets up the session & the slicer, indexer and queues used
+// to smash files, nothing is logged without a Logger.
+func (app *App) Initialise() error {
+	af := app.Flags
+	if err := app.validateArgs(); err != nil {
+		return err
 	}
 
-	if af.Profile {
-		profiler.InitialiseProfiler()
+	if app.Progress == nil {
+		app.Progress = NewAppProgress()
+	}
+	if app.Logger == nil {
+		app.Logger = slog.New(slog.DiscardHandler)
 	}
 
 	app.Session = &AppSession{
@@ -70,220 +131,243 @@ func (app *App) Run() error {
 			Files:   []File{},
 			RWMutex: sync.RWMutex{},
 		},
+		Skipped:   newSkippedFiles(af.ReportSkipped),
 		StartTime: time.Now().UnixNano(),
 		EndTime:   -1,
 	}
 
-	// Validate and convert slice parameters
-	if af.SliceSize < 0 || af.SliceThreshold < 0 {
-		return fmt.Errorf("slice size and threshold must be non-negative")
-	}
-	if af.MinSize < 0 || af.MaxSize < 0 {
-		return fmt.Errorf("min size and max size must be non-negative")
-	}
-	sl := slicer.NewConfigured(algorithms.Algorithm(af.Algorithm), af.Slices, uint64(af.SliceSize), uint64(af.SliceThreshold))
+	sl := slicer.NewConfigured(af.PrimaryAlgorithm(), af.Slices, uint64(af.SliceSize), uint64(af.SliceThreshold))
+	sl.UseDigests(af.DigestAlgorithms()...)
 	wk := indexer.NewConfigured(af.ExcludeDir, af.ExcludeFile, af.IgnoreHidden, af.IgnoreSystem)
 	slo := slicer.Options{
 		DisableSlicing:  af.DisableSlicing,
 		DisableMeta:     af.DisableMeta,
 		DisableAutoText: af.DisableAutoText,
+		DisableFormats:  af.DisableFormats,
+		AdaptiveSlices:  af.AdaptiveSlices,
+		ParallelReads:   af.ParallelReads,
 		MinSize:         uint64(af.MinSize),
 		MaxSize:         uint64(af.MaxSize),
 	}
 
-	app.Runtime = &AppRuntime{
-		Slicer:        &sl,
-		SlicerOptions: &slo,
-		IndexerConfig: wk,
-		Files:         make(chan *indexer.FileFS),
+	var known *KnownHashes
+	if len(af.Against) > 0 {
+		var err error
+		scanned := append([]algorithms.Algorithm{af.PrimaryAlgorithm()}, af.DigestAlgorithms()...)
+		if known, err = LoadKnownHashes(af.Against, scanned...); err != nil {
+			return err
+		}
+		if err := known.checkAlgorithms(scanned); err != nil {
+			return err
+		}
+	}
+
+	var retries *xsync.Map[string, *indexer.FileFS]
+	if af.RetryFailed > 0 {
+		retries = xsync.NewMap[string, *indexer.FileFS]()
 	}
 
-	app.setMaxThreads()
+	app.Runtime = &AppRuntime{
+		Known:          known,
+		Retries:        retries,
+		Slicer:         &sl,
+		SlicerOptions:  &slo,
+		IndexerConfig:  wk,
+		LocationLimits: app.buildLocationLimits(),
+		Queues:         app.buildDeviceQueues(),
+	}
 
-	return app.Exec()
+	app.subscribe()
+	return nil
 }
-func (app *App) Exec() error {
-	if err := app.validateArgs(); err != nil {
+func (app *App) Exec(ctx context.Context) error {
+	stopMetrics, err := app.startMetrics()
+	if err != nil {
 		return err
 	}
-
-	startStats := nerdstats.Snapshot()
-
-	// Setup progress display
-	pap := app.setupProgressDisplay()
-
-	// Start indexing
-	app.startIndexing(pap)
-
-	// Process files
-	totalFiles := app.processFiles(pap)
-
-	// Finalize analysis
-	app.finalizeAnalysis(pap, totalFiles)
-
-	// Clean up progress display
-	if pap != nil {
-		pap.Stop()
+	defer stopMetrics()
+	if err := app.openCheckpoint(); err != nil {
+		return err
+	}
+	if app.Flags.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, app.Flags.Timeout)
+		defer cancel()
 	}
 
-	// Print results and statistics
-	app.printResultsAndStats(startStats)
+	indexed := app.startIndexing(ctx)
+	totalFiles := app.processFiles(ctx)
+	<-indexed
+	app.retryFailed(ctx)
+	app.closeCheckpoint()
+	app.finalizeAnalysis(totalFiles)
 
-	return nil
-}
+	app.scanFinished()
+	app.logFinished()
 
-func (app *App) setupProgressDisplay() *pterm.MultiPrinter {
-	if !app.Output.ShouldShowProgress() {
-		return nil
+	if app.Session.Partial {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("smashing timed out after %s, results are partial", app.Flags.Timeout)
+		}
+		return errors.New("smashing was interrupted, results are partial")
 	}
-	mp := theme.MultiWriter()
-	pap := &mp
-	pap.Start()
-	return pap
+	return nil
 }
 
-func (app *App) startIndexing(pap *pterm.MultiPrinter) {
-	wk := app.Runtime.IndexerConfig
-	files := app.Runtime.Files
-	locations := app.Locations
-	isVerbose := app.Output.IsVerbose()
-	walkOptions := indexer.WalkConfig{Recurse: app.Flags.Recurse}
-	session := app.Session
+// startIndexing Walks the locations in the background, the channel is closed once they've all been walked.
+func (app *App) startIndexing(ctx context.Context) <-chan struct{} {
+	queues := app.Runtime.Queues
+	app.stageStarted(StageIndexing)
 
-	psi := app.Output.StartSpinner(theme.IndexingSpinner(), "Indexing locations...", pap)
-	go func() {
-		defer func() {
-			close(files)
-			psi.Success("Indexing locations...Done!")
+	var wg sync.WaitGroup
+	for _, queue := range queues {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				close(queue.Files)
+				wg.Done()
+			}()
+			app.indexQueue(ctx, queue)
 		}()
-		for _, location := range locations {
-			psi.UpdateText("Indexing location: " + location.Name)
-			err := wk.WalkDirectory(location.FS, location.Name, walkOptions, files)
-
-			if err != nil {
-				if isVerbose {
-					theme.WarnSkipWithContext(location.Name, err)
-				}
-				_, _ = session.Fails.LoadAndStore(location.Name, err)
-			}
-		}
+	}
+	indexed := make(chan struct{})
+	go func() {
+		defer close(indexed)
+		wg.Wait()
+		app.stageFinished(StageIndexing, ctx.Err() != nil)
 	}()
+	return indexed
 }
 
-func (app *App) processFiles(pap *pterm.MultiPrinter) int64 {
+// processFiles Smashes files as they're indexed, when the context is done the
+// session is marked partial & only files smashed until then are kept.
+func (app *App) processFiles(ctx context.Context) int64 {
 	sl := app.Runtime.Slicer
 	slo := app.Runtime.SlicerOptions
-	files := app.Runtime.Files
-	isVerbose := app.Output.IsVerbose()
+	queues := app.Runtime.Queues
+	limits := app.Runtime.LocationLimits
 	session := app.Session
 
-	totalFiles := xsync.NewCounter()
-	pss := app.Output.StartSpinner(theme.SmashingSpinner(), "Finding duplicates...", pap)
-
-	updateProgressTicker := make(chan bool)
-	if app.Output.ShouldShowProgress() {
-		app.updateDupeCount(updateProgressTicker, pss, totalFiles)
-	}
+	totalFiles := app.Progress.Files
+	app.stageStarted(StageSmashing)
 
 	var wg sync.WaitGroup
-	for i := 0; i < app.Flags.MaxWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for file := range files {
-				totalFiles.Inc()
-				app.processFile(file, sl, slo, session, isVerbose)
-			}
-		}()
+	for _, queue := range queues {
+		for i := 0; i < queue.Workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for file := range queue.Files {
+					if ctx.Err() != nil {
+						return
+					}
+					totalFiles.Inc()
+					app.Metrics.observeIndexed()
+					app.fileIndexed(file)
+					if app.resumeFile(file) {
+						continue
+					}
+					release := limits.Acquire(file)
+					app.processFile(ctx, file, sl, slo)
+					release()
+				}
+			}()
+		}
 	}
 	wg.Wait()
 
-	if app.Output.ShouldShowProgress() {
-		updateProgressTicker <- true
+	if ctx.Err() != nil {
+		session.Partial = true
 	}
-
-	pss.Success("Finding duplicates...Done!")
+	app.stageFinished(StageSmashing, session.Partial)
 	return totalFiles.Value()
 }
 
-func (app *App) processFile(file *indexer.FileFS, sl *slicer.Slicer, slo *slicer.Options, session *AppSession, isVerbose bool) {
+func (app *App) processFile(ctx context.Context, file *indexer.FileFS, sl *slicer.Slicer, slo *slicer.Options) {
 	startTime := time.Now().UnixMilli()
-	stats, err := sl.SliceFS(*file.FileSystem, file.Path, slo)
+	stats, err := sl.SliceFS(ctx, *file.FileSystem, file.Path, slo)
 	elapsedMs := time.Now().UnixMilli() - startTime
 
 	switch {
+	case err != nil && ctx.Err() != nil:
+		// stopped part way through, the file wasn't smashed rather than failed
+		app.Progress.Files.Dec()
 	case err != nil:
-		if isVerbose {
-			theme.WarnSkipWithContext(file.FullName, err)
-		}
-		_, _ = session.Fails.LoadOrStore(file.Path, err)
+		app.recordFail(file, err)
+	default:
+		app.smashed(file, stats, elapsedMs)
+	}
+}
+
+// smashed Adds a file that was read successfully to the session, unless it was ignored.
+func (app *App) smashed(file *indexer.FileFS, stats slicer.SlicerStats, elapsedMs int64) {
+	session := app.Session
+	switch {
+	case stats.IgnoredFile && stats.EmptyFile:
+		// empty files are tracked rather than skipped
+		app.fileSmashed(SummariseSmashedFile(stats, file, elapsedMs, session.Dupes, session.Empty))
+	case stats.IgnoredMode:
+		app.recordSkip(file.FullName, file.Location, false, indexer.SkipSpecial, nil)
 	case stats.IgnoredFile:
-		// Check if it's an empty file that should be tracked
-		if stats.EmptyFile {
-			SummariseSmashedFile(stats, file, elapsedMs, session.Dupes, session.Empty)
-		}
+		app.recordSkip(file.FullName, file.Location, false, indexer.SkipSize, nil)
 	default:
-		SummariseSmashedFile(stats, file, elapsedMs, session.Dupes, session.Empty)
+		app.fileSmashed(SummariseSmashedFile(stats, file, elapsedMs, session.Dupes, session.Empty))
+		app.Metrics.observeHashed(stats, elapsedMs)
+		app.Logger.Debug("smashed file", "path", file.FullName, "location", file.Location, "size", stats.FileSize, "strategy", stats.Strategy, "elapsed_ms", elapsedMs)
 	}
 }
 
-func (app *App) finalizeAnalysis(pap *pterm.MultiPrinter, totalFiles int64) {
+func (app *App) finalizeAnalysis(totalFiles int64) {
 	app.Session.EndTime = time.Now().UnixNano()
 
-	psr := app.Output.StartSpinner(theme.FinaliseSpinner(), "Finding smash hits...", pap)
+	app.stageStarted(StageFinalising)
+	manifest := app.ExportManifest()
+	app.matchKnown()
+	app.groupsFormed()
 	app.generateRunSummary(totalFiles)
-	psr.Success("Finding smash hits...Done!")
+	app.Summary.Manifest = manifest
+	app.Metrics.observeDupes(app.Session.Dupes)
+	app.pushMetrics()
+	app.stageFinished(StageFinalising, false)
 }
 
-func (app *App) printResultsAndStats(startStats nerdstats.NerdStats) {
-	app.PrintRunAnalysis(app.Flags.IgnoreEmpty)
-
-	midStats := nerdstats.Snapshot()
-	app.ExportReport()
-	exportStats := nerdstats.Snapshot()
-
-	if !app.Output.IsSilent() {
-		PrintRunSummary(*app.Summary, app.Flags)
+// pushMetrics Pushes the final metrics to --metrics-push, a run that can't be scraped once it exits still reports them.
+func (app *App) pushMetrics() {
+	if app.Flags.MetricsPush == "" || app.Metrics == nil {
+		return
 	}
-
-	endStats := nerdstats.Snapshot()
-
-	if app.Flags.ShowNerdStats && !app.Output.IsSilent() {
-		theme.StyleHeading.Println("---| Nerd Stats")
-		PrintNerdStats(startStats, "> Initial")
-		PrintNerdStats(midStats, "> Post-Analysis")
-		PrintNerdStats(exportStats, "> Post-Summary")
-		PrintNerdStats(endStats, "> Post-Report")
+	if err := app.Metrics.Push(app.Flags.MetricsPush); err != nil {
+		app.Logger.Error("failed to push metrics", "url", app.Flags.MetricsPush, "error", err)
 	}
 }
 
-func (app *App) updateDupeCount(updateProgressTicker chan bool, pss SpinnerHandle, totalFiles *xsync.Counter) {
-	if !app.Output.ShouldShowProgress() {
-		return
-	}
-	go func() {
-		ticker := time.Tick(time.Duration(app.Flags.ProgressUpdate) * time.Second)
-		for {
-			select {
-			case <-ticker:
-				pss.UpdateText(fmt.Sprintf("Finding duplicates... (%s files smash'd)", pterm.Gray(totalFiles.Value())))
-			case <-updateProgressTicker:
-				return
-			}
-		}
-	}()
+// Finalise Summarises what a Watch smashed & tells the observers, Run does this itself.
+func (app *App) Finalise(totalFiles int64) {
+	app.finalizeAnalysis(totalFiles)
+	app.scanFinished()
+}
 
+// logFinished Logs the summary for log aggregation, the console prints its own.
+func (app *App) logFinished() {
+	summary := app.Summary
+	app.Logger.Info("smashing finished",
+		"files", summary.TotalFiles,
+		"unique", summary.UniqueFiles,
+		"duplicates", summary.DuplicateFiles,
+		"fails", summary.TotalFileErrors,
+		"fail_codes", summary.FailCodes,
+		"reclaimable", summary.DuplicateFileSize,
+		"partial", summary.Partial,
+		"elapsed_ms", time.Duration(summary.ElapsedTime).Milliseconds())
 }
 
 func (app *App) ExportReport() {
-	if !app.Output.ShouldGenerateReport() {
+	if app.Flags.HideOutput {
 		return
 	}
 
 	if filename, err := app.Export(app.Flags.OutputFile); err != nil {
-		if !app.Output.IsSilent() {
-			theme.Error.Println("Failed to export report because ", err)
-		}
+		app.Logger.Error("failed to export report", "path", app.Flags.OutputFile, "error", err)
 	} else {
 		app.Summary.ReportFilename = filename
 	}
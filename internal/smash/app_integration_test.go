@@ -9,7 +9,7 @@
 	"testing"
 	"time"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 	"github.com/thushan/smash/pkg/indexer"
 	"golang.org/x/sync/errgroup"
 )
@@ -74,7 +74,7 @@ func TestAppConcurrentFileProcessing(t *testing.T) {
 		t.Run(fmt.Sprintf("workers_%d", workers), func(t *testing.T) {
 			app := &App{
 				Flags: &Flags{
-					Algorithm:       int(algorithms.Xxhash),
+					Algorithms:      []int{int(algorithms.Xxhash)},
 					MaxWorkers:      workers,
 					MaxThreads:      workers,
 					Slices:          4,
@@ -94,9 +94,9 @@ func TestAppConcurrentFileProcessing(t *testing.T) {
 				},
 			}
 
-			err := app.Run()
+			err := app.Run(context.Background())
 			if err != nil {
-				t.Errorf("app.Run() failed with %d workers: %v", workers, err)
+				t.Errorf("app.Run(context.Background()) failed with %d workers: %v", workers, err)
 			}
 
 			// Verify results
@@ -159,7 +159,7 @@ func TestAppWithInvalidConfiguration(t *testing.T) {
 				Args:  []string{"."},
 			}
 
-			err := app.Run()
+			err := app.Run(context.Background())
 			if tt.wantError {
 				if err == nil {
 					t.Error("expected error but got nil")
@@ -203,7 +203,7 @@ func TestAppMemoryUsageUnderLoad(t *testing.T) {
 
 	app := &App{
 		Flags: &Flags{
-			Algorithm:      int(algorithms.Xxhash),
+			Algorithms:     []int{int(algorithms.Xxhash)},
 			MaxWorkers:     16,
 			MaxThreads:     16,
 			Slices:         4,
@@ -227,11 +227,11 @@ func TestAppMemoryUsageUnderLoad(t *testing.T) {
 	runtime.ReadMemStats(&memBefore)
 
 	start := time.Now()
-	err := app.Run()
+	err := app.Run(context.Background())
 	duration := time.Since(start)
 
 	if err != nil {
-		t.Errorf("app.Run() failed: %v", err)
+		t.Errorf("app.Run(context.Background()) failed: %v", err)
 	}
 
 	// Measure memory after
@@ -280,7 +280,7 @@ func TestAppRaceConditions(t *testing.T) {
 		g.Go(func() error {
 			app := &App{
 				Flags: &Flags{
-					Algorithm:       int(algorithms.Xxhash),
+					Algorithms:      []int{int(algorithms.Xxhash)},
 					MaxWorkers:      4,
 					MaxThreads:      4,
 					Slices:          4,
@@ -299,7 +299,7 @@ func TestAppRaceConditions(t *testing.T) {
 				},
 			}
 
-			return app.Run()
+			return app.Run(context.Background())
 		})
 	}
 
@@ -1,9 +1,15 @@
 package smash
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
 )
 
 func TestAppValidateArgs(t *testing.T) {
@@ -114,19 +120,19 @@ func TestAppValidateArgs(t *testing.T) {
 func TestAppSessionInitialisation(t *testing.T) {
 	app := &App{
 		Flags: &Flags{
-			Algorithm:      int(algorithms.Xxhash),
+			Algorithms:     []int{int(algorithms.Xxhash)},
 			MaxWorkers:     4,
 			MaxThreads:     4,
 			SliceSize:      8192,
 			SliceThreshold: 102400,
 			Slices:         4,
+			ShowTop:        10,
+			ProgressUpdate: 5,
 		},
 	}
 
-	// Just test the session initialisation part
-	err := app.Run()
-	if err == nil {
-		t.Error("expected error due to missing locations")
+	if err := app.Initialise(); err != nil {
+		t.Fatal(err)
 	}
 
 	// Verify session was created
@@ -146,3 +152,56 @@ func TestAppSessionInitialisation(t *testing.T) {
 		}
 	}
 }
+
+func TestAppInitialiseValidatesFirst(t *testing.T) {
+	app := &App{
+		Flags: &Flags{
+			Algorithms:     []int{int(algorithms.Xxhash)},
+			Against:        []string{filepath.Join(t.TempDir(), "missing.sha256")},
+			MetricsAddress: "127.0.0.1:0",
+			Silent:         true,
+			Verbose:        true,
+		},
+	}
+
+	if err := app.Run(context.Background()); err == nil || err.Error() != "cannot be verbose and silent" {
+		t.Errorf("expected the flags to be rejected, got %v", err)
+	}
+	if app.Session != nil || app.Runtime != nil || app.Metrics != nil {
+		t.Error("expected nothing to be set up for invalid flags")
+	}
+}
+
+func TestAppTimeoutReportsPartial(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "DSC19841.ARW"), []byte("smash"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	app := &App{
+		Flags: &Flags{
+			Algorithms:     []int{int(algorithms.Xxhash)},
+			MaxWorkers:     1,
+			MaxThreads:     1,
+			SliceSize:      8192,
+			SliceThreshold: 102400,
+			Slices:         4,
+			ShowTop:        10,
+			ProgressUpdate: 5,
+			Silent:         true,
+			HideOutput:     true,
+			Timeout:        time.Nanosecond,
+		},
+		Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, os.DirFS(tempDir))},
+	}
+
+	err := app.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timed out error, got %v", err)
+	}
+	if !app.Summary.Partial {
+		t.Error("expected the summary to be partial")
+	}
+	if report := app.GenerateReportOutput(); !report.Summary.Partial {
+		t.Error("expected the report to be partial")
+	}
+}
@@ -0,0 +1,265 @@
+package smash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+const (
+	DefaultCheckpointInterval = 30 * time.Second
+	checkpointVersion         = 1
+)
+
+// Checkpoint records every smashed file to a state file as JSON lines, so an
+// interrupted scan can be resumed with --resume without rehashing them. Lines
+// are buffered & flushed every interval, a crash loses at most that much.
+type Checkpoint struct {
+	fs       *os.File
+	buffer   *bufio.Writer
+	encoder  *json.Encoder
+	done     chan struct{}
+	Filename string
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// checkpointHeader is the first line of a state file, resuming with settings
+// that change how files are hashed would mix hashes that can never match.
+type checkpointHeader struct {
+	Settings checkpointSettings `json:"settings"`
+	Version  int                `json:"version"`
+}
+
+type checkpointSettings struct {
+	Algorithms      string `json:"algorithms"`
+	MinSize         int64  `json:"minSize"`
+	MaxSize         int64  `json:"maxSize"`
+	SliceThreshold  int64  `json:"sliceThreshold"`
+	SliceSize       int64  `json:"sliceSize"`
+	Slices          int    `json:"slices"`
+	DisableSlicing  bool   `json:"disableSlicing"`
+	DisableMeta     bool   `json:"disableMeta"`
+	DisableAutoText bool   `json:"disableAutoText"`
+	DisableFormats  bool   `json:"disableFormats"`
+	AdaptiveSlices  bool   `json:"adaptiveSlices"`
+}
+
+func newCheckpointSettings(f *Flags) checkpointSettings {
+	names := []string{f.PrimaryAlgorithm().String()}
+	for _, algorithm := range f.DigestAlgorithms() {
+		names = append(names, algorithm.String())
+	}
+	return checkpointSettings{
+		Algorithms:      strings.Join(names, ","),
+		MinSize:         f.MinSize,
+		MaxSize:         f.MaxSize,
+		SliceThreshold:  f.SliceThreshold,
+		SliceSize:       f.SliceSize,
+		Slices:          f.Slices,
+		DisableSlicing:  f.DisableSlicing,
+		DisableMeta:     f.DisableMeta,
+		DisableAutoText: f.DisableAutoText,
+		DisableFormats:  f.DisableFormats,
+		AdaptiveSlices:  f.AdaptiveSlices,
+	}
+}
+
+// CreateCheckpoint Starts a new state file, refusing to overwrite one that could still be resumed.
+func CreateCheckpoint(filename string, flags *Flags, interval time.Duration) (*Checkpoint, error) {
+	// #nosec G304 -- the state file is given by the user
+	fs, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("state file %s already exists, use --resume to continue it", filename)
+		}
+		return nil, err
+	}
+	c := newCheckpoint(fs, filename)
+	if err := c.encoder.Encode(checkpointHeader{Version: checkpointVersion, Settings: newCheckpointSettings(flags)}); err != nil {
+		_ = fs.Close()
+		return nil, err
+	}
+	c.start(interval)
+	return c, nil
+}
+
+// ResumeCheckpoint Loads the files smashed by a previous run from its state file
+// & keeps recording to it. A line cut short by a crash is dropped.
+func ResumeCheckpoint(filename string, flags *Flags, interval time.Duration) (*Checkpoint, *xsync.Map[string, File], error) {
+	// #nosec G304 -- the state file is given by the user
+	fs, err := os.OpenFile(filename, os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, offset, err := readCheckpoint(fs, newCheckpointSettings(flags))
+	if err == nil {
+		err = fs.Truncate(offset)
+	}
+	if err == nil {
+		_, err = fs.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		_ = fs.Close()
+		return nil, nil, fmt.Errorf("failed to resume from %s: %w", filename, err)
+	}
+	c := newCheckpoint(fs, filename)
+	c.start(interval)
+	return c, files, nil
+}
+
+// readCheckpoint Returns the files in a state file by their full name, along
+// with the offset of the last complete line to continue writing from.
+func readCheckpoint(r io.Reader, settings checkpointSettings) (*xsync.Map[string, File], int64, error) {
+	br := bufio.NewReader(r)
+	files := xsync.NewMap[string, File]()
+	offset := int64(0)
+
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, 0, errors.New("not a smash state file")
+	}
+	var header checkpointHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.Version != checkpointVersion {
+		return nil, 0, errors.New("not a smash state file")
+	}
+	if header.Settings != settings {
+		return nil, 0, errors.New("state file was smashed with different algorithm or slicing options, resume with the same flags")
+	}
+	offset += int64(len(line))
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// anything left is a line the last run didn't finish writing
+			return files, offset, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		var file File
+		if err := json.Unmarshal(bytes.TrimSpace(line), &file); err != nil {
+			return nil, 0, fmt.Errorf("corrupt state file at byte %d: %w", offset, err)
+		}
+		files.Store(filepath.Join(file.Location, file.Path), file)
+		offset += int64(len(line))
+	}
+}
+
+func newCheckpoint(fs *os.File, filename string) *Checkpoint {
+	buffer := bufio.NewWriter(fs)
+	return &Checkpoint{
+		fs:       fs,
+		buffer:   buffer,
+		encoder:  json.NewEncoder(buffer),
+		done:     make(chan struct{}),
+		Filename: filename,
+	}
+}
+
+func (c *Checkpoint) start(interval time.Duration) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = c.Flush()
+			case <-c.done:
+				return
+			}
+		}
+	}()
+}
+
+// Record Adds a smashed file to the state file, it's written on the next flush.
+func (c *Checkpoint) Record(file File) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.encoder.Encode(file)
+}
+
+// Flush Writes recorded files to disk.
+func (c *Checkpoint) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.buffer.Flush(); err != nil {
+		return err
+	}
+	return c.fs.Sync()
+}
+
+// Close Flushes & closes the state file, removing it when the scan finished
+// since there's nothing left to resume.
+func (c *Checkpoint) Close(finished bool) error {
+	if c == nil {
+		return nil
+	}
+	close(c.done)
+	c.wg.Wait()
+	err := errors.Join(c.Flush(), c.fs.Close())
+	if finished && err == nil {
+		err = os.Remove(c.Filename)
+	}
+	return err
+}
+
+// openCheckpoint Starts the state file of --checkpoint, or loads the one given to --resume.
+func (app *App) openCheckpoint() error {
+	af := app.Flags
+	var err error
+	switch {
+	case af.ResumeFile != "":
+		app.Runtime.Checkpoint, app.Runtime.Resumed, err = ResumeCheckpoint(af.ResumeFile, af, af.CheckpointInterval)
+	case af.CheckpointFile != "":
+		app.Runtime.Checkpoint, err = CreateCheckpoint(af.CheckpointFile, af, af.CheckpointInterval)
+	}
+	return err
+}
+
+// closeCheckpoint Flushes the state file, it's only kept when smashing was interrupted.
+func (app *App) closeCheckpoint() {
+	if err := app.Runtime.Checkpoint.Close(!app.Session.Partial); err != nil {
+		app.Logger.Error("failed to save state file", "error", err)
+	}
+}
+
+// resumeFile Adds the file as it was smashed by the run being resumed, unless
+// it has been modified or replaced since. Returns false when it still needs smashing.
+func (app *App) resumeFile(file *indexer.FileFS) bool {
+	if app.Runtime.Resumed == nil {
+		return false
+	}
+	previous, ok := app.Runtime.Resumed.LoadAndDelete(file.FullName)
+	if !ok || !unchanged(previous.Meta, file.Meta) {
+		return false
+	}
+	addSmashedFile(previous, app.Session.Dupes, app.Session.Empty)
+	app.notifySmashed(previous)
+	return true
+}
+
+// unchanged Reports whether a file is the one that was smashed, a copy restored
+// with its mod time kept has a different inode or device where the platform has them.
+func unchanged(previous indexer.FileMeta, current indexer.FileMeta) bool {
+	return previous.ModTime.Equal(current.ModTime) &&
+		previous.Size == current.Size &&
+		previous.Inode == current.Inode &&
+		previous.Device == current.Device
+}
@@ -0,0 +1,199 @@
+package smash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+func checkpointFlags() *Flags {
+	return &Flags{
+		Algorithms:     []int{int(algorithms.Xxhash)},
+		Slices:         4,
+		SliceSize:      8192,
+		SliceThreshold: 102400,
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "smash.state")
+	flags := checkpointFlags()
+
+	c, err := CreateCheckpoint(filename, flags, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Record(File{Location: "/mnt/c", Path: "dos/run.exe", Hash: "1984", FileSize: 4})
+	c.Record(File{Location: "/mnt/c", Path: "empty.txt", EmptyFile: true})
+	if err := c.Close(false); err != nil {
+		t.Fatal(err)
+	}
+
+	// a crash part way through writing a line
+	fs, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.WriteString(`{"Location":"/mnt/c","Pa`); err != nil {
+		t.Fatal(err)
+	}
+	_ = fs.Close()
+
+	c, files, err := ResumeCheckpoint(filename, flags, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files.Size() != 2 {
+		t.Errorf("expected 2 files, got %d", files.Size())
+	}
+	if file, ok := files.Load(filepath.Join("/mnt/c", "dos/run.exe")); !ok || file.Hash != "1984" {
+		t.Errorf("expected run.exe with hash 1984, got %v", file)
+	}
+	c.Record(File{Location: "/mnt/c", Path: "dos/run.bat", Hash: "1985", FileSize: 4})
+	if err := c.Close(false); err != nil {
+		t.Fatal(err)
+	}
+
+	c, files, err = ResumeCheckpoint(filename, flags, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files.Size() != 3 {
+		t.Errorf("expected 3 files, got %d", files.Size())
+	}
+	if err := c.Close(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("expected state file to be removed once finished, got %v", err)
+	}
+}
+
+func TestCheckpointRejects(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "smash.state")
+	c, err := CreateCheckpoint(filename, checkpointFlags(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CreateCheckpoint(filename, checkpointFlags(), time.Hour); err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Errorf("expected an existing state file to be refused, got %v", err)
+	}
+
+	flags := checkpointFlags()
+	flags.DisableSlicing = true
+	if _, _, err := ResumeCheckpoint(filename, flags, time.Hour); err == nil {
+		t.Error("expected resuming with different slicing to fail")
+	}
+
+	report := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(report, []byte(`{"summary":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ResumeCheckpoint(report, checkpointFlags(), time.Hour); err == nil {
+		t.Error("expected resuming from a report to fail")
+	}
+}
+
+func TestAppResume(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("smash"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(tempDir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newApp := func(flags *Flags) *App {
+		flags.MaxWorkers = 1
+		flags.MaxThreads = 1
+		flags.ShowTop = 10
+		flags.ProgressUpdate = 5
+		flags.Silent = true
+		flags.HideOutput = true
+		flags.CheckpointInterval = time.Hour
+		return &App{
+			Flags:     flags,
+			Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, os.DirFS(tempDir))},
+		}
+	}
+
+	// interrupted before anything was smashed, the state file is kept
+	filename := filepath.Join(t.TempDir(), "smash.state")
+	flags := checkpointFlags()
+	flags.CheckpointFile = filename
+	app := newApp(flags)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.Run(ctx); err == nil {
+		t.Error("expected an interrupted run to fail")
+	}
+	if !app.Summary.Partial || app.Summary.TotalFiles != 0 {
+		t.Errorf("expected a partial summary of 0 files, got %+v", app.Summary)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Fatalf("expected state file to be kept, got %v", err)
+	}
+
+	// a.txt was smashed before the interruption, only b.txt should be hashed
+	c, _, err := ResumeCheckpoint(filename, checkpointFlags(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Record(File{Location: tempDir, Path: "a.txt", Filename: "a.txt", Hash: "feed", FileSize: 5, Meta: indexer.ReadMeta(fi)})
+	if err := c.Close(false); err != nil {
+		t.Fatal(err)
+	}
+
+	flags = checkpointFlags()
+	flags.ResumeFile = filename
+	app = newApp(flags)
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if app.Summary.Partial || app.Summary.TotalFiles != 2 {
+		t.Errorf("expected a full summary of 2 files, got %+v", app.Summary)
+	}
+	// rehashing a.txt would make it a duplicate of b.txt
+	if app.Summary.DuplicateFiles != 0 {
+		t.Errorf("expected a.txt to be resumed rather than rehashed, got %d duplicates", app.Summary.DuplicateFiles)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("expected state file to be removed once finished, got %v", err)
+	}
+}
+
+func TestResumeUnchanged(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	smashed := indexer.FileMeta{ModTime: modTime, Size: 5, Inode: 1984, Device: 64}
+	tests := []struct {
+		name    string
+		current indexer.FileMeta
+		want    bool
+	}{
+		{"same file", smashed, true},
+		{"modified", indexer.FileMeta{ModTime: modTime.Add(time.Second), Size: 5, Inode: 1984, Device: 64}, false},
+		{"resized with its mod time kept", indexer.FileMeta{ModTime: modTime, Size: 6, Inode: 1984, Device: 64}, false},
+		{"replaced by a copy", indexer.FileMeta{ModTime: modTime, Size: 5, Inode: 1985, Device: 64}, false},
+		{"moved to another device", indexer.FileMeta{ModTime: modTime, Size: 5, Inode: 1984, Device: 65}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unchanged(smashed, tt.current); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
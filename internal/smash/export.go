@@ -1,70 +1,131 @@
 package smash
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	user2 "os/user"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/thushan/smash/pkg/analysis"
+	"github.com/thushan/smash/pkg/indexer"
 )
 
+// ReportSchemaVersion is bumped whenever the shape of the report changes, see `smash schema`
+const ReportSchemaVersion = 2
+
 type ReportOutput struct {
 	Meta     ReportMeta    `json:"_meta"`
 	Analysis ReportFiles   `json:"analysis"`
 	Summary  ReportSummary `json:"summary"`
 }
 type ReportMeta struct {
-	Timestamp time.Time `json:"timestamp"`
-	Config    *Flags    `json:"config"`
-	Version   string    `json:"version"`
-	Commit    string    `json:"commit"`
-	Host      string    `json:"host"`
-	User      string    `json:"user"`
+	Timestamp     time.Time `json:"timestamp"`
+	Config        *Flags    `json:"config"`
+	Version       string    `json:"version"`
+	Commit        string    `json:"commit"`
+	Host          string    `json:"host"`
+	User          string    `json:"user"`
+	Locations     []string  `json:"locations"`
+	SchemaVersion int       `json:"schemaVersion"`
 }
 type ReportSummary struct {
-	TopFiles          []ReportTopFilesSummary `json:"top"`
-	DuplicateFileSize uint64                  `json:"duplicateFileSize"`
-	TotalFiles        int64                   `json:"totalFiles"`
-	TotalFileErrors   int64                   `json:"totalFileFails"`
-	ElapsedTime       int64                   `json:"elapsedTime"`
-	UniqueFiles       int64                   `json:"uniqueFiles"`
-	EmptyFiles        int64                   `json:"emptyFiles"`
-	DuplicateFiles    int64                   `json:"duplicateFiles"`
+	Skipped           map[indexer.SkipReason]int64 `json:"skipped,omitempty"`
+	FailCodes         map[FailCode]int64           `json:"failCodes,omitempty"`
+	TopFiles          []ReportTopFilesSummary      `json:"top"`
+	DuplicateFileSize uint64                       `json:"duplicateFileSize"`
+	TotalFiles        int64                        `json:"totalFiles"`
+	TotalFileErrors   int64                        `json:"totalFileFails"`
+	ElapsedTime       int64                        `json:"elapsedTime"`
+	UniqueFiles       int64                        `json:"uniqueFiles"`
+	EmptyFiles        int64                        `json:"emptyFiles"`
+	DuplicateFiles    int64                        `json:"duplicateFiles"`
+	KnownFiles        int64                        `json:"knownFiles,omitempty"`
+	Partial           bool                         `json:"partial,omitempty"`
 }
 type ReportTopFilesSummary struct {
+	ID   string `json:"id"`
 	Hash string `json:"hash"`
 	Size uint64 `json:"size"`
 }
 type ReportFiles struct {
-	Fails []ReportFailSummary      `json:"fails"`
-	Empty []ReportFileBaseSummary  `json:"empty"`
-	Dupes []ReportDuplicateSummary `json:"dupes"`
+	Fails    []ReportFailSummary      `json:"fails"`
+	Empty    []ReportFileBaseSummary  `json:"empty"`
+	Dupes    []ReportDuplicateSummary `json:"dupes"`
+	Known    []ReportKnownSummary     `json:"known,omitempty"`
+	Skipped  []ReportSkipSummary      `json:"skipped,omitempty"`
+	Overlaps []ReportOverlapSummary   `json:"overlaps,omitempty"`
 }
 
 type ReportFailSummary struct {
-	Filename string `json:"filename"`
-	Error    string `json:"error"`
+	Filename string   `json:"filename"`
+	Path     string   `json:"path"`
+	Error    string   `json:"error"`
+	Code     FailCode `json:"code"`
+}
+
+type ReportSkipSummary struct {
+	Path   string             `json:"path"`
+	Reason indexer.SkipReason `json:"reason"`
+	Dir    bool               `json:"dir,omitempty"`
+}
+
+type ReportOverlapSummary struct {
+	DirA  string `json:"dirA"`
+	DirB  string `json:"dirB"`
+	Size  uint64 `json:"size"`
+	Files int64  `json:"files"`
 }
 
 type ReportFileBaseSummary struct {
-	Filename string `json:"filename"`
-	Location string `json:"location"`
-	Path     string `json:"path"`
+	Filename      string    `json:"filename"`
+	Location      string    `json:"location"`
+	Path          string    `json:"path"`
+	RelativePath  string    `json:"relativePath"`
+	ModTime       time.Time `json:"modTime,omitzero"`
+	ChangeTime    time.Time `json:"changeTime,omitzero"`
+	Mode          string    `json:"mode"`
+	Owner         string    `json:"owner,omitempty"`
+	Inode         uint64    `json:"inode,omitempty"`
+	Links         uint64    `json:"links,omitempty"`
+	LocationIndex int       `json:"locationIndex"`
+	UID           int       `json:"uid"`
+	GID           int       `json:"gid"`
 }
 
 type ReportFileSummary struct {
 	ReportFileBaseSummary
-	Hash     string `json:"hash"`
-	Size     uint64 `json:"size"`
-	FullHash bool   `json:"fullHash"`
+	Digests     map[string]string `json:"digests,omitempty"`
+	Hash        string            `json:"hash"`
+	ContentType string            `json:"contentType,omitempty"`
+	Strategy    string            `json:"strategy,omitempty"`
+	Size        uint64            `json:"size"`
+	SliceSize   uint64            `json:"sliceSize,omitempty"`
+	Slices      int               `json:"slices,omitempty"`
+	FullHash    bool              `json:"fullHash"`
 }
-type ReportDuplicateSummary struct {
-	Duplicates []ReportFileSummary `json:"duplicates"`
+type ReportKnownSummary struct {
 	ReportFileSummary
+	Source    string `json:"source"`
+	KnownPath string `json:"knownPath,omitempty"`
+}
+type ReportDuplicateSummary struct {
+	ID          string              `json:"id"`
+	Hash        string              `json:"hash"`
+	ContentType string              `json:"contentType,omitempty"`
+	Files       []ReportFileSummary `json:"files"`
+	Size        uint64              `json:"size"`
+}
+
+// ReportLocations resolves files back to the absolute location they were found in.
+type ReportLocations struct {
+	index map[string]int
+	paths []string
 }
 
 func (app *App) Export(filePath string) (string, error) {
@@ -92,21 +153,24 @@ func (app *App) ExportFile(f *os.File) error {
 }
 
 func (app *App) GenerateReportOutput() ReportOutput {
+	locations := newReportLocations(app.Locations)
 	return ReportOutput{
 		Summary:  summariseRunSummary(app.Summary),
-		Analysis: summariseRunAnalysis(app.Session),
-		Meta:     summariseMeta(app.Flags),
+		Analysis: summariseRunAnalysis(app.Session, app.Summary, locations),
+		Meta:     summariseMeta(app.Flags, locations),
 	}
 }
 
-func summariseMeta(flags *Flags) ReportMeta {
+func summariseMeta(flags *Flags, locations ReportLocations) ReportMeta {
 	return ReportMeta{
-		Version:   Version,
-		Commit:    Commit,
-		Config:    flags,
-		Host:      getHostName(),
-		User:      getUsername(),
-		Timestamp: time.Now(),
+		SchemaVersion: ReportSchemaVersion,
+		Version:       Version,
+		Commit:        Commit,
+		Config:        flags,
+		Locations:     locations.paths,
+		Host:          getHostName(),
+		User:          getUsername(),
+		Timestamp:     time.Now(),
 	}
 }
 
@@ -124,16 +188,56 @@ func getHostName() string {
 	return "Classified"
 }
 
-func summariseRunAnalysis(session *AppSession) ReportFiles {
+func newReportLocations(locations []indexer.LocationFS) ReportLocations {
+	rl := ReportLocations{
+		index: make(map[string]int, len(locations)),
+		paths: make([]string, len(locations)),
+	}
+	for i, location := range locations {
+		rl.index[location.Name] = i
+		rl.paths[i] = absolutePath(location.Name)
+	}
+	return rl
+}
+
+// resolve Returns the index & absolute path of a location, -1 if it's not one we scanned
+func (rl ReportLocations) resolve(location string) (int, string) {
+	if i, ok := rl.index[location]; ok {
+		return i, rl.paths[i]
+	}
+	return -1, absolutePath(location)
+}
+
+func absolutePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// GroupID Returns a stable identifier for a group of duplicates, derived from
+// the hash & size so the same group has the same ID across runs.
+func GroupID(hash string, size uint64) string {
+	id := sha256.Sum256([]byte(hash + ":" + strconv.FormatUint(size, 10)))
+	return hex.EncodeToString(id[:8])
+}
+
+func summariseRunAnalysis(session *AppSession, summary *RunSummary, locations ReportLocations) ReportFiles {
 
 	fails := summariseSmashFails(session.Fails)
-	empty := summariseEmptyFiles(session.Empty.Files)
-	dupes := transformDupes(session.Dupes)
+	empty := summariseEmptyFiles(session.Empty.Files, locations)
+	dupes := transformDupes(session.Dupes, locations)
+	known := summariseKnownFiles(session.Known, locations)
+	skipped := summariseSkippedFiles(session.Skipped)
+	overlaps := summariseDirectoryOverlaps(summary.Overlaps)
 
 	return ReportFiles{
-		Fails: fails,
-		Empty: empty,
-		Dupes: dupes,
+		Fails:    fails,
+		Empty:    empty,
+		Dupes:    dupes,
+		Known:    known,
+		Skipped:  skipped,
+		Overlaps: overlaps,
 	}
 }
 
@@ -143,7 +247,9 @@ func summariseSmashFails(fails *xsync.Map[string, error]) []ReportFailSummary {
 	fails.Range(func(key string, value error) bool {
 		summary[index] = ReportFailSummary{
 			Filename: key,
+			Path:     absolutePath(key),
 			Error:    value.Error(),
+			Code:     ClassifyFail(value),
 		}
 		index++
 		return true
@@ -151,15 +257,17 @@ func summariseSmashFails(fails *xsync.Map[string, error]) []ReportFailSummary {
 	return summary
 }
 
-func transformDupes(duplicates *xsync.Map[string, *DuplicateFiles]) []ReportDuplicateSummary {
+func transformDupes(duplicates *xsync.Map[string, *DuplicateFiles], locations ReportLocations) []ReportDuplicateSummary {
 	dupes := make([]ReportDuplicateSummary, duplicates.Size())
 	var index = 0
 	duplicates.Range(func(hash string, dupe *DuplicateFiles) bool {
 		root := dupe.Files[0]
-		rest := dupe.Files[1:]
 		dupes[index] = ReportDuplicateSummary{
-			ReportFileSummary: summariseSmashedFile(root),
-			Duplicates:        summariseSmashedFiles(rest),
+			ID:          GroupID(hash, root.FileSize),
+			Hash:        hash,
+			Size:        root.FileSize,
+			ContentType: root.ContentType,
+			Files:       summariseSmashedFiles(dupe.Files, locations),
 		}
 		index++
 		return true
@@ -167,31 +275,65 @@ func transformDupes(duplicates *xsync.Map[string, *DuplicateFiles]) []ReportDupl
 	return dupes
 }
 
-func summariseEmptyFiles(files []File) []ReportFileBaseSummary {
+func summariseKnownFiles(matches []KnownMatch, locations ReportLocations) []ReportKnownSummary {
+	if len(matches) == 0 {
+		return nil
+	}
+	summary := make([]ReportKnownSummary, len(matches))
+	for i, match := range matches {
+		summary[i] = ReportKnownSummary{
+			ReportFileSummary: summariseSmashedFile(match.File, locations),
+			Source:            match.Known.Source,
+			KnownPath:         match.Known.Path,
+		}
+	}
+	return summary
+}
+func summariseEmptyFiles(files []File, locations ReportLocations) []ReportFileBaseSummary {
 	summary := make([]ReportFileBaseSummary, len(files))
 	for i, file := range files {
-		summary[i] = summariseSmashedFile(file).ReportFileBaseSummary
+		summary[i] = summariseSmashedFile(file, locations).ReportFileBaseSummary
 	}
 	return summary
 }
-func summariseSmashedFiles(files []File) []ReportFileSummary {
+func summariseSmashedFiles(files []File, locations ReportLocations) []ReportFileSummary {
 	summary := make([]ReportFileSummary, len(files))
 	for i, file := range files {
-		summary[i] = summariseSmashedFile(file)
+		summary[i] = summariseSmashedFile(file, locations)
 	}
 	return summary
 }
-func summariseSmashedFile(file File) ReportFileSummary {
-	return ReportFileSummary{
+func summariseSmashedFile(file File, locations ReportLocations) ReportFileSummary {
+	locationIndex, location := locations.resolve(file.Location)
+	summary := ReportFileSummary{
 		ReportFileBaseSummary: ReportFileBaseSummary{
-			Filename: file.Filename,
-			Location: file.Location,
-			Path:     filepath.Dir(file.Path),
+			Filename:      file.Filename,
+			Location:      file.Location,
+			LocationIndex: locationIndex,
+			Path:          filepath.Join(location, file.Path),
+			RelativePath:  filepath.ToSlash(file.Path),
+			ModTime:       file.Meta.ModTime,
+			ChangeTime:    file.Meta.ChangeTime,
+			Mode:          file.Meta.Mode.String(),
+			Owner:         file.Meta.Owner,
+			Inode:         file.Meta.Inode,
+			Links:         file.Meta.Links,
+			UID:           file.Meta.UID,
+			GID:           file.Meta.GID,
 		},
-		Hash:     file.Hash,
-		Size:     file.FileSize,
-		FullHash: file.FullHash,
+		Hash:        file.Hash,
+		Digests:     file.Digests,
+		ContentType: file.ContentType,
+		Strategy:    file.Strategy,
+		Size:        file.FileSize,
+		FullHash:    file.FullHash,
+	}
+	// the slicing parameters only matter to a sliced hash, a text file is full hashed
+	if !file.FullHash {
+		summary.Slices = file.Slices
+		summary.SliceSize = file.SliceSize
 	}
+	return summary
 }
 func summariseRunSummary(summary *RunSummary) ReportSummary {
 	return ReportSummary{
@@ -199,10 +341,14 @@ func summariseRunSummary(summary *RunSummary) ReportSummary {
 		DuplicateFileSize: summary.DuplicateFileSize,
 		TotalFiles:        summary.TotalFiles,
 		TotalFileErrors:   summary.TotalFileErrors,
+		FailCodes:         summary.FailCodes,
+		Skipped:           summary.Skipped,
 		ElapsedTime:       summary.ElapsedTime,
 		UniqueFiles:       summary.UniqueFiles,
 		EmptyFiles:        summary.EmptyFiles,
 		DuplicateFiles:    summary.DuplicateFiles,
+		KnownFiles:        summary.KnownFiles,
+		Partial:           summary.Partial,
 	}
 }
 
@@ -210,6 +356,7 @@ func transformTopFiles(files []analysis.Item) []ReportTopFilesSummary {
 	items := make([]ReportTopFilesSummary, len(files))
 	for i, file := range files {
 		items[i] = ReportTopFilesSummary{
+			ID:   GroupID(file.Key, file.Size),
 			Hash: file.Key,
 			Size: file.Size,
 		}
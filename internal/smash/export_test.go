@@ -2,9 +2,14 @@
 
 import (
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/thushan/smash/pkg/analysis"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/slicer"
 )
 
 func TestGetUsername(t *testing.T) {
@@ -42,7 +47,7 @@ func TestTransformTopFiles(t *testing.T) {
 				{Key: "hash1", Size: 100},
 			},
 			expected: []ReportTopFilesSummary{
-				{Hash: "hash1", Size: 100},
+				{ID: GroupID("hash1", 100), Hash: "hash1", Size: 100},
 			},
 		},
 		{
@@ -53,9 +58,9 @@ func TestTransformTopFiles(t *testing.T) {
 				{Key: "hash3", Size: 300},
 			},
 			expected: []ReportTopFilesSummary{
-				{Hash: "hash1", Size: 100},
-				{Hash: "hash2", Size: 200},
-				{Hash: "hash3", Size: 300},
+				{ID: GroupID("hash1", 100), Hash: "hash1", Size: 100},
+				{ID: GroupID("hash2", 200), Hash: "hash2", Size: 200},
+				{ID: GroupID("hash3", 300), Hash: "hash3", Size: 300},
 			},
 		},
 	}
@@ -72,7 +77,7 @@ func TestTransformTopFiles(t *testing.T) {
 
 			// Check each item
 			for i, item := range result {
-				if item.Hash != tt.expected[i].Hash || item.Size != tt.expected[i].Size {
+				if item != tt.expected[i] {
 					t.Errorf("transformTopFiles()[%d] = {Hash: %s, Size: %d}, want {Hash: %s, Size: %d}",
 						i, item.Hash, item.Size, tt.expected[i].Hash, tt.expected[i].Size)
 				}
@@ -84,7 +89,9 @@ func TestTransformTopFiles(t *testing.T) {
 func TestSummariseSmashedFile(t *testing.T) {
 	// Use filepath.Join to create platform-specific paths
 	testPath := filepath.Join("path", "to", "test.txt")
-	expectedDir := filepath.Dir(testPath)
+	location, _ := filepath.Abs("location1")
+	locations := newReportLocations([]indexer.LocationFS{{Name: "location0"}, {Name: "location1"}})
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
 
 	tests := []struct {
 		name     string
@@ -103,9 +110,109 @@ func TestSummariseSmashedFile(t *testing.T) {
 			},
 			expected: ReportFileSummary{
 				ReportFileBaseSummary: ReportFileBaseSummary{
-					Filename: "test.txt",
-					Location: "location1",
-					Path:     expectedDir,
+					Filename:      "test.txt",
+					Location:      "location1",
+					LocationIndex: 1,
+					Path:          filepath.Join(location, testPath),
+					RelativePath:  "path/to/test.txt",
+					Mode:          "----------",
+				},
+				Hash:     "hash1",
+				Size:     100,
+				FullHash: true,
+			},
+		},
+		{
+			name: "Should record slicing parameters for sliced files",
+			file: File{
+				Filename:  "test.txt",
+				Location:  "location1",
+				Path:      testPath,
+				Hash:      "hash1",
+				Strategy:  "uniform",
+				Slices:    4,
+				SliceSize: 8192,
+				FileSize:  1024000,
+			},
+			expected: ReportFileSummary{
+				ReportFileBaseSummary: ReportFileBaseSummary{
+					Filename:      "test.txt",
+					Location:      "location1",
+					LocationIndex: 1,
+					Path:          filepath.Join(location, testPath),
+					RelativePath:  "path/to/test.txt",
+					Mode:          "----------",
+				},
+				Hash:      "hash1",
+				Strategy:  "uniform",
+				Slices:    4,
+				SliceSize: 8192,
+				Size:      1024000,
+			},
+		},
+		{
+			name: "Should record the strategy of full hashed text files",
+			file: File{
+				Filename:  "readme.md",
+				Location:  "location1",
+				Path:      testPath,
+				Hash:      "hash1",
+				Strategy:  "text",
+				FullHash:  true,
+				Slices:    4,
+				SliceSize: 8192,
+				FileSize:  1024000,
+			},
+			expected: ReportFileSummary{
+				ReportFileBaseSummary: ReportFileBaseSummary{
+					Filename:      "readme.md",
+					Location:      "location1",
+					LocationIndex: 1,
+					Path:          filepath.Join(location, testPath),
+					RelativePath:  "path/to/test.txt",
+					Mode:          "----------",
+				},
+				Hash:     "hash1",
+				Strategy: "text",
+				Size:     1024000,
+				FullHash: true,
+			},
+		},
+		{
+			name: "Should include file metadata",
+			file: File{
+				Filename: "test.txt",
+				Location: "location1",
+				Path:     testPath,
+				Hash:     "hash1",
+				FullHash: true,
+				FileSize: 100,
+				Meta: indexer.FileMeta{
+					ModTime:    modTime,
+					ChangeTime: modTime.Add(time.Hour),
+					Mode:       0o640,
+					Owner:      "thushan",
+					Inode:      1984,
+					Links:      2,
+					UID:        1000,
+					GID:        100,
+				},
+			},
+			expected: ReportFileSummary{
+				ReportFileBaseSummary: ReportFileBaseSummary{
+					Filename:      "test.txt",
+					Location:      "location1",
+					LocationIndex: 1,
+					Path:          filepath.Join(location, testPath),
+					RelativePath:  "path/to/test.txt",
+					ModTime:       modTime,
+					ChangeTime:    modTime.Add(time.Hour),
+					Mode:          "-rw-r-----",
+					Owner:         "thushan",
+					Inode:         1984,
+					Links:         2,
+					UID:           1000,
+					GID:           100,
 				},
 				Hash:     "hash1",
 				Size:     100,
@@ -116,26 +223,69 @@ func TestSummariseSmashedFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := summariseSmashedFile(tt.file)
+			result := summariseSmashedFile(tt.file, locations)
 
-			// Check fields
-			if result.Filename != tt.expected.Filename {
-				t.Errorf("summariseSmashedFile().Filename = %s, want %s", result.Filename, tt.expected.Filename)
-			}
-			if result.Location != tt.expected.Location {
-				t.Errorf("summariseSmashedFile().Location = %s, want %s", result.Location, tt.expected.Location)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("summariseSmashedFile() = %+v, want %+v", result, tt.expected)
 			}
-			if result.Path != tt.expected.Path {
-				t.Errorf("summariseSmashedFile().Path = %s, want %s", result.Path, tt.expected.Path)
-			}
-			if result.Hash != tt.expected.Hash {
-				t.Errorf("summariseSmashedFile().Hash = %s, want %s", result.Hash, tt.expected.Hash)
+		})
+	}
+}
+
+func TestSummariseSmashedFileOutsideLocations(t *testing.T) {
+	locations := newReportLocations(nil)
+	result := summariseSmashedFile(File{Location: "elsewhere", Path: "file.txt"}, locations)
+
+	if result.LocationIndex != -1 {
+		t.Errorf("expected location index -1, got %d", result.LocationIndex)
+	}
+	if !filepath.IsAbs(result.Path) {
+		t.Errorf("expected absolute path, got %s", result.Path)
+	}
+}
+
+func TestGroupID(t *testing.T) {
+	a := GroupID("0a1b2c", 1024)
+
+	if a != GroupID("0a1b2c", 1024) {
+		t.Error("expected group id to be stable")
+	}
+	if a == GroupID("0a1b2c", 2048) {
+		t.Error("expected group id to change with size")
+	}
+	if a == GroupID("0a1b2d", 1024) {
+		t.Error("expected group id to change with hash")
+	}
+	if len(a) != 16 {
+		t.Errorf("expected 16 character group id, got %s", a)
+	}
+}
+
+func TestSummariseSmashedFileDigests(t *testing.T) {
+	tests := []struct {
+		name     string
+		fullHash bool
+		digests  bool
+	}{
+		{name: "Should keep digests of fully hashed files", fullHash: true, digests: true},
+		{name: "Should drop digests of sliced files", fullHash: false, digests: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := slicer.SlicerStats{
+				Hash:           []byte{0x19, 0x84},
+				Digests:        map[string][]byte{"xxhash": {0x19, 0x84}, "md5": {0x20, 0x24}},
+				FileSize:       1024000,
+				HashedFullFile: tt.fullHash,
 			}
-			if result.Size != tt.expected.Size {
-				t.Errorf("summariseSmashedFile().Size = %d, want %d", result.Size, tt.expected.Size)
+			ffs := &indexer.FileFS{Name: "DSC19841.ARW", Path: "DSC19841.ARW", Location: "location0"}
+			file := SummariseSmashedFile(stats, ffs, 0, xsync.NewMap[string, *DuplicateFiles](), &EmptyFiles{})
+
+			if actual := file.Digests != nil; actual != tt.digests {
+				t.Errorf("expected digests %v, got %v", tt.digests, file.Digests)
 			}
-			if result.FullHash != tt.expected.FullHash {
-				t.Errorf("summariseSmashedFile().FullHash = %t, want %t", result.FullHash, tt.expected.FullHash)
+			if tt.digests && file.Digests["md5"] != "2024" {
+				t.Errorf("expected the md5 digest 2024, got %q", file.Digests["md5"])
 			}
 		})
 	}
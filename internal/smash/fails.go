@@ -0,0 +1,144 @@
+package smash
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+// FailCode Says why a file or location couldn't be read, so fails can be
+// counted & acted on without matching error strings.
+type FailCode string
+
+const (
+	// FailPermission is a file or location smash isn't allowed to read, ie. ACLs
+	FailPermission FailCode = "permission"
+	// FailVanished is a file or location that was deleted or moved after it was found
+	FailVanished FailCode = "vanished"
+	// FailTooLarge is a slice or region too large to read into memory
+	FailTooLarge FailCode = "too-large"
+	// FailUnsupported is a file system that can't be read the way smash needs
+	FailUnsupported FailCode = "unsupported"
+	// FailTimeout is a read that timed out or would've blocked, ie. on a network share
+	FailTimeout FailCode = "timeout"
+	// FailBusy is a file locked or in use by something else
+	FailBusy FailCode = "busy"
+	// FailUnstable is a file that changed while it was being hashed, ie. a download in progress
+	FailUnstable FailCode = "unstable"
+	// FailIO is any other error reading a file, ie. a bad sector
+	FailIO FailCode = "io"
+)
+
+const DefaultRetryBackoff = time.Second
+
+// ClassifyFail Returns the code for an error reading a file or location.
+func ClassifyFail(err error) FailCode {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, slicer.ErrFileChanged):
+		return FailUnstable
+	case errors.Is(err, fs.ErrPermission):
+		return FailPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return FailVanished
+	case errors.Is(err, slicer.ErrBufferTooLarge):
+		return FailTooLarge
+	case errors.Is(err, errors.ErrUnsupported):
+		return FailUnsupported
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return FailTimeout
+	case busy(err):
+		return FailBusy
+	default:
+		return FailIO
+	}
+}
+
+// Transient Returns true for fails that may not happen again, which --retry-failed retries.
+func (c FailCode) Transient() bool {
+	return c == FailIO || c == FailTimeout || c == FailBusy || c == FailUnstable
+}
+
+// countFails Returns the number of fails for each code.
+func countFails(fails *xsync.Map[string, error]) map[FailCode]int64 {
+	counts := make(map[FailCode]int64)
+	fails.Range(func(_ string, err error) bool {
+		counts[ClassifyFail(err)]++
+		return true
+	})
+	return counts
+}
+
+// recordFail Records a file that couldn't be smashed, keeping it for
+// --retry-failed when the error may be transient.
+func (app *App) recordFail(file *indexer.FileFS, err error) {
+	code := ClassifyFail(err)
+	app.Logger.Info("skipped file", "path", file.FullName, "location", file.Location, "kind", code, "error", err)
+	if _, loaded := app.Session.Fails.LoadOrStore(file.FullName, err); !loaded {
+		app.Progress.Fails.Inc()
+		app.Metrics.observeFail()
+		app.fileFailed(file.FullName, err)
+	}
+	if app.Runtime.Retries != nil && code.Transient() {
+		app.Runtime.Retries.Store(file.FullName, file)
+	}
+}
+
+// retryFailed Smashes files that failed with a transient error again, up to
+// --retry-failed times, doubling the wait between each attempt.
+func (app *App) retryFailed(ctx context.Context) {
+	retries := app.Runtime.Retries
+	if retries == nil || retries.Size() == 0 {
+		return
+	}
+	app.stageStarted(StageRetrying)
+
+	backoff := app.Flags.RetryBackoff
+	for attempt := 1; attempt <= app.Flags.RetryFailed && retries.Size() > 0; attempt++ {
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		backoff *= 2
+
+		retries.Range(func(name string, file *indexer.FileFS) bool {
+			release := app.Runtime.LocationLimits.Acquire(file)
+			defer release()
+
+			app.Logger.Info("retrying file", "path", name, "location", file.Location, "attempt", attempt)
+			startTime := time.Now().UnixMilli()
+			stats, err := app.Runtime.Slicer.SliceFS(ctx, *file.FileSystem, file.Path, app.Runtime.SlicerOptions)
+			elapsedMs := time.Now().UnixMilli() - startTime
+
+			switch {
+			case ctx.Err() != nil:
+				return false
+			case err != nil:
+				app.Session.Fails.Store(name, err)
+				if !ClassifyFail(err).Transient() {
+					retries.Delete(name)
+				}
+			default:
+				retries.Delete(name)
+				app.Session.Fails.Delete(name)
+				app.Progress.Fails.Dec()
+				app.smashed(file, stats, elapsedMs)
+			}
+			return true
+		})
+	}
+	// files still to retry weren't smashed, so the session is partial like any other interruption
+	stopped := ctx.Err() != nil
+	if stopped {
+		app.Session.Partial = true
+	}
+	app.stageFinished(StageRetrying, stopped)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package smash
+
+import (
+	"errors"
+	"syscall"
+)
+
+// busy Returns true for a file locked or in use by something else.
+func busy(err error) bool {
+	return errors.Is(err, syscall.EBUSY)
+}
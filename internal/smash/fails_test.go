@@ -0,0 +1,164 @@
+package smash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+func TestClassifyFail(t *testing.T) {
+	tests := []struct {
+		err  error
+		want FailCode
+	}{
+		{&fs.PathError{Op: "open", Path: "pagefile.sys", Err: fs.ErrPermission}, FailPermission},
+		{&fs.PathError{Op: "open", Path: "run.exe", Err: fs.ErrNotExist}, FailVanished},
+		{fmt.Errorf("slice size %w", slicer.ErrBufferTooLarge), FailTooLarge},
+		{slicer.ErrReaderUnsupported, FailUnsupported},
+		{&fs.PathError{Op: "hash", Path: "download.iso", Err: slicer.ErrFileChanged}, FailUnstable},
+		{context.DeadlineExceeded, FailTimeout},
+		{&fs.PathError{Op: "read", Path: "nas.iso", Err: os.ErrDeadlineExceeded}, FailTimeout},
+		{errors.New("input/output error"), FailIO},
+	}
+	for _, tt := range tests {
+		if got := ClassifyFail(tt.err); got != tt.want {
+			t.Errorf("expected %q for %v, got %q", tt.want, tt.err, got)
+		}
+	}
+	if !FailIO.Transient() || !FailUnstable.Transient() || FailPermission.Transient() {
+		t.Error("expected only io, timeout, busy & unstable fails to be transient")
+	}
+}
+
+// flakyFS Fails to open a file with an I/O error the first few times.
+type flakyFS struct {
+	fs.FS
+	name  string
+	fails int32
+	opens atomic.Int32
+}
+
+func (f *flakyFS) Open(name string) (fs.File, error) {
+	if name == f.name && f.opens.Add(1) <= f.fails {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EIO}
+	}
+	return f.FS.Open(name)
+}
+
+func (f *flakyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.FS, name)
+}
+
+func TestAppRetryFailed(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		fails     int32
+		wantFails int64
+		wantDupes int64
+	}{
+		{"retried until it's read", 2, 2, 0, 1},
+		{"still failing after the retries", 1, 2, 1, 0},
+		{"not retried", 0, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for _, name := range []string{"DSC19841.ARW", "DSC19842.ARW"} {
+				if err := os.WriteFile(tempDir+"/"+name, []byte("smash"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			flaky := &flakyFS{FS: os.DirFS(tempDir), name: "DSC19842.ARW", fails: tt.fails}
+			app := &App{
+				Flags: &Flags{
+					Algorithms:     []int{int(algorithms.Xxhash)},
+					MaxWorkers:     1,
+					MaxThreads:     1,
+					SliceSize:      8192,
+					SliceThreshold: 102400,
+					Slices:         4,
+					ShowTop:        10,
+					ProgressUpdate: 5,
+					Silent:         true,
+					HideOutput:     true,
+					RetryFailed:    tt.retries,
+					RetryBackoff:   time.Millisecond,
+				},
+				Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, flaky)},
+			}
+			if err := app.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if app.Summary.TotalFileErrors != tt.wantFails {
+				t.Errorf("expected %d fails, got %d", tt.wantFails, app.Summary.TotalFileErrors)
+			}
+			if tt.wantFails > 0 && app.Summary.FailCodes[FailIO] != tt.wantFails {
+				t.Errorf("expected %d io fails, got %v", tt.wantFails, app.Summary.FailCodes)
+			}
+			if app.Summary.DuplicateFiles != tt.wantDupes {
+				t.Errorf("expected %d duplicates, got %d", tt.wantDupes, app.Summary.DuplicateFiles)
+			}
+		})
+	}
+}
+
+// cancelOnStage Cancels the run as the stage starts.
+type cancelOnStage struct {
+	NopObserver
+	cancel context.CancelFunc
+	stage  Stage
+}
+
+func (c cancelOnStage) StageStarted(stage Stage) {
+	if stage == c.stage {
+		c.cancel()
+	}
+}
+
+func TestAppRetryFailedInterrupted(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"DSC19841.ARW", "DSC19842.ARW"} {
+		if err := os.WriteFile(tempDir+"/"+name, []byte("smash"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	flaky := &flakyFS{FS: os.DirFS(tempDir), name: "DSC19842.ARW", fails: 1}
+	app := &App{
+		Flags: &Flags{
+			Algorithms:     []int{int(algorithms.Xxhash)},
+			MaxWorkers:     1,
+			SliceSize:      8192,
+			SliceThreshold: 102400,
+			Slices:         4,
+			ShowTop:        10,
+			ProgressUpdate: 5,
+			HideOutput:     true,
+			RetryFailed:    2,
+			RetryBackoff:   time.Hour,
+		},
+		Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, flaky)},
+		Observers: []Observer{cancelOnStage{cancel: cancel, stage: StageRetrying}},
+	}
+	if err := app.Run(ctx); err == nil {
+		t.Error("expected an interrupted retry to fail")
+	}
+	if !app.Summary.Partial {
+		t.Error("expected the summary to be partial when retrying was interrupted")
+	}
+	if app.Summary.TotalFileErrors != 1 {
+		t.Errorf("expected the file still to have failed, got %d fails", app.Summary.TotalFileErrors)
+	}
+}
@@ -3,41 +3,91 @@
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/manifest"
 	"github.com/thushan/smash/pkg/slicer"
 )
 
 type Flags struct {
-	OutputFile      string   `yaml:"output"`
-	Base            []string `yaml:"base"`
-	ExcludeDir      []string `yaml:"exclude-dir"`
-	ExcludeFile     []string `yaml:"exclude-file"`
-	MinSize         int64    `yaml:"min-size"`
-	MaxSize         int64    `yaml:"max-size"`
-	SliceThreshold  int64    `yaml:"slice-threshold"`
-	SliceSize       int64    `yaml:"slice-size"`
-	Slices          int      `yaml:"slices"`
-	Algorithm       int      `yaml:"algorithm"`
-	MaxThreads      int      `yaml:"max-threads"`
-	MaxWorkers      int      `yaml:"max-workers"`
-	ProgressUpdate  int      `yaml:"progress-update"`
-	ShowTop         int      `yaml:"show-top"`
-	DisableSlicing  bool     `yaml:"disable-slicing"`
-	DisableMeta     bool     `yaml:"disable-meta"`
-	DisableAutoText bool     `yaml:"disable-autotext"`
-	IgnoreEmpty     bool     `yaml:"ignore-empty"`
-	IgnoreHidden    bool     `yaml:"ignore-hidden"`
-	IgnoreSystem    bool     `yaml:"ignore-system"`
-	ShowVersion     bool     `yaml:"version"`
-	ShowNerdStats   bool     `yaml:"nerd-stats"`
-	Recurse         bool     `yaml:"recurse"`
-	ShowDuplicates  bool     `yaml:"show-duplicates"`
-	Silent          bool     `yaml:"silent"`
-	HideTopList     bool     `yaml:"no-top-list"`
-	HideProgress    bool     `yaml:"no-progress"`
-	HideOutput      bool     `yaml:"no-output"`
-	Profile         bool     `yaml:"profile"`
-	Verbose         bool     `yaml:"verbose"`
+	LocationWorkers    map[string]int `yaml:"location-workers"`
+	OutputFile         string         `yaml:"output"`
+	ManifestFile       string         `yaml:"export-manifest"`
+	ServeAddress       string         `yaml:"addr"`
+	MetricsAddress     string         `yaml:"metrics-addr"`
+	MetricsPush        string         `yaml:"metrics-push"`
+	CheckpointFile     string         `yaml:"checkpoint"`
+	ResumeFile         string         `yaml:"resume"`
+	LogFormat          string         `yaml:"log-format"`
+	LogFile            string         `yaml:"log-file"`
+	Against            []string       `yaml:"against"`
+	Base               []string       `yaml:"base"`
+	ExcludeDir         []string       `yaml:"exclude-dir"`
+	ExcludeFile        []string       `yaml:"exclude-file"`
+	Algorithms         []int          `yaml:"algorithm"`
+	MinSize            int64          `yaml:"min-size"`
+	MaxSize            int64          `yaml:"max-size"`
+	SliceThreshold     int64          `yaml:"slice-threshold"`
+	SliceSize          int64          `yaml:"slice-size"`
+	WatchSettle        time.Duration  `yaml:"settle"`
+	Timeout            time.Duration  `yaml:"timeout"`
+	CheckpointInterval time.Duration  `yaml:"checkpoint-interval"`
+	RetryBackoff       time.Duration  `yaml:"retry-backoff"`
+	Slices             int            `yaml:"slices"`
+	ServeRetain        int            `yaml:"retain"`
+	ManifestFormat     int            `yaml:"manifest-format"`
+	MaxThreads         int            `yaml:"max-threads"`
+	MaxWorkers         int            `yaml:"max-workers"`
+	HddWorkers         int            `yaml:"hdd-workers"`
+	RetryFailed        int            `yaml:"retry-failed"`
+	ProgressUpdate     int            `yaml:"progress-update"`
+	ShowTop            int            `yaml:"show-top"`
+	DisableSlicing     bool           `yaml:"disable-slicing"`
+	DisableMeta        bool           `yaml:"disable-meta"`
+	DisableAutoText    bool           `yaml:"disable-autotext"`
+	DisableFormats     bool           `yaml:"disable-formats"`
+	AdaptiveSlices     bool           `yaml:"adaptive-slices"`
+	ParallelReads      bool           `yaml:"parallel-reads"`
+	IgnoreEmpty        bool           `yaml:"ignore-empty"`
+	IgnoreHidden       bool           `yaml:"ignore-hidden"`
+	IgnoreSystem       bool           `yaml:"ignore-system"`
+	ShowVersion        bool           `yaml:"version"`
+	ShowNerdStats      bool           `yaml:"nerd-stats"`
+	Recurse            bool           `yaml:"recurse"`
+	ShowDuplicates     bool           `yaml:"show-duplicates"`
+	Silent             bool           `yaml:"silent"`
+	HideTopList        bool           `yaml:"no-top-list"`
+	HideProgress       bool           `yaml:"no-progress"`
+	HideOutput         bool           `yaml:"no-output"`
+	Profile            bool           `yaml:"profile"`
+	Verbose            bool           `yaml:"verbose"`
+	ReportSkipped      bool           `yaml:"report-skipped"`
+	Interactive        bool           `yaml:"interactive"`
+	WatchJSON          bool           `yaml:"json"`
+}
+
+// PrimaryAlgorithm Returns the algorithm files are compared by, the first given to --algorithm.
+func (f *Flags) PrimaryAlgorithm() algorithms.Algorithm {
+	if len(f.Algorithms) == 0 {
+		return algorithms.Xxhash
+	}
+	return algorithms.Algorithm(f.Algorithms[0])
+}
+
+// DigestAlgorithms Returns the additional algorithms given to --algorithm, reported alongside the primary.
+func (f *Flags) DigestAlgorithms() []algorithms.Algorithm {
+	if len(f.Algorithms) < 2 {
+		return nil
+	}
+	digests := make([]algorithms.Algorithm, 0, len(f.Algorithms)-1)
+	for _, algorithm := range f.Algorithms[1:] {
+		digests = append(digests, algorithms.Algorithm(algorithm))
+	}
+	return digests
 }
 
 func (app *App) validateArgs() error {
@@ -45,12 +95,30 @@ func (app *App) validateArgs() error {
 	if f.Silent && f.Verbose {
 		return errors.New("cannot be verbose and silent")
 	}
+	for _, algorithm := range f.Algorithms {
+		if !algorithms.Algorithm(algorithm).Available() {
+			return fmt.Errorf("hash algorithm #%d isn't registered", algorithm)
+		}
+	}
+	if f.ManifestFile != "" {
+		if err := manifest.Format(f.ManifestFormat).Supports(f.PrimaryAlgorithm()); err != nil {
+			return err
+		}
+	}
 	if f.MaxThreads < 0 {
 		return errors.New("maxthreads cannot be below zero")
 	}
 	if f.MaxWorkers < 0 {
 		return errors.New("maxworkers cannot be below zero")
 	}
+	if f.HddWorkers < 0 {
+		return errors.New("hddworkers cannot be below zero")
+	}
+	for location, workers := range f.LocationWorkers {
+		if workers < 1 {
+			return fmt.Errorf("location workers for %q must be at least 1", location)
+		}
+	}
 	if f.SliceSize < 0 || f.SliceThreshold < 0 {
 		return errors.New("slice size and threshold must be non-negative")
 	}
@@ -66,6 +134,35 @@ func (app *App) validateArgs() error {
 	if f.ShowTop != 10 && f.HideTopList {
 		return errors.New("cannot mix showtop x and hidetop")
 	}
+	if f.CheckpointFile != "" && f.ResumeFile != "" {
+		return errors.New("cannot checkpoint to a new state file while resuming, --resume keeps checkpointing")
+	}
+	if (f.CheckpointFile != "" || f.ResumeFile != "") && f.CheckpointInterval <= 0 {
+		return errors.New("checkpoint interval must be greater than zero")
+	}
+	if f.MetricsPush != "" {
+		if u, err := url.Parse(f.MetricsPush); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("metrics push %q must be an http(s) Pushgateway URL", f.MetricsPush)
+		}
+	}
+	if f.LogFormat != "" && !slices.Contains(LogFormats, f.LogFormat) {
+		return fmt.Errorf("unsupported log format %q, use one of %s", f.LogFormat, strings.Join(LogFormats, ", "))
+	}
+	if f.RetryFailed < 0 {
+		return errors.New("retry failed cannot be negative")
+	}
+	if f.RetryFailed > 0 && f.RetryBackoff <= 0 {
+		return errors.New("retry backoff must be greater than zero")
+	}
+	if f.Interactive && f.Silent {
+		return errors.New("cannot review interactively and be silent")
+	}
+	if f.Timeout < 0 {
+		return errors.New("timeout cannot be negative")
+	}
+	if f.WatchSettle < 0 {
+		return errors.New("settle cannot be negative")
+	}
 	if f.ProgressUpdate < 1 {
 		return errors.New("updateseconds cannot be less than 1")
 	}
@@ -1,8 +1,10 @@
 package smash
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/thushan/smash/pkg/algorithms"
 	"github.com/thushan/smash/pkg/slicer"
 )
 
@@ -20,6 +22,22 @@ func TestApp_ValidateArgs(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Should fail when interactive and silent are both true",
+			flags: &Flags{
+				Silent:      true,
+				Interactive: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should fail when a sha256sum manifest would be xxhash",
+			flags: &Flags{
+				ManifestFile: "photos.sha256",
+				Algorithms:   []int{int(algorithms.Xxhash)},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Should fail when maxThreads is below zero",
 			flags: &Flags{
@@ -49,6 +67,38 @@ func TestApp_ValidateArgs(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Should fail when the log format isn't supported",
+			flags: &Flags{
+				ShowTop:   10,
+				LogFormat: "syslog",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should fail when the metrics push URL isn't http",
+			flags: &Flags{
+				ShowTop:     10,
+				MetricsPush: "pushgateway:9091",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should fail when checkpointing and resuming",
+			flags: &Flags{
+				CheckpointFile:     "smash.state",
+				ResumeFile:         "smash.state",
+				CheckpointInterval: DefaultCheckpointInterval,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should fail when checkpoint interval is zero",
+			flags: &Flags{
+				CheckpointFile: "smash.state",
+			},
+			wantErr: true,
+		},
 		{
 			name: "Should fail when progressUpdate is below 1",
 			flags: &Flags{
@@ -196,3 +246,41 @@ func TestApp_ValidateArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestFlags_Algorithms(t *testing.T) {
+	tests := []struct {
+		name            string
+		primary         algorithms.Algorithm
+		digests         []algorithms.Algorithm
+		flagsAlgorithms []int
+	}{
+		{
+			name:    "Should default to xxhash",
+			primary: algorithms.Xxhash,
+		},
+		{
+			name:            "Should use a single algorithm",
+			flagsAlgorithms: []int{int(algorithms.Sha256)},
+			primary:         algorithms.Sha256,
+		},
+		{
+			name:            "Should use the first algorithm as primary",
+			flagsAlgorithms: []int{int(algorithms.Sha256), int(algorithms.Md5), int(algorithms.Sha1)},
+			primary:         algorithms.Sha256,
+			digests:         []algorithms.Algorithm{algorithms.Md5, algorithms.Sha1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := &Flags{Algorithms: tt.flagsAlgorithms}
+
+			if actual := flags.PrimaryAlgorithm(); actual != tt.primary {
+				t.Errorf("expected primary %v, got %v", tt.primary, actual)
+			}
+			if actual := flags.DigestAlgorithms(); !reflect.DeepEqual(actual, tt.digests) {
+				t.Errorf("expected digests %v, got %v", tt.digests, actual)
+			}
+		})
+	}
+}
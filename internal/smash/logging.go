@@ -0,0 +1,9 @@
+package smash
+
+// Formats for --log-format, without one (or --log-file) messages are styled for the console.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var LogFormats = []string{LogFormatText, LogFormatJSON}
@@ -0,0 +1,125 @@
+package smash
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/manifest"
+)
+
+// ManifestSummary records what made it into the manifest, only full hashed
+// files have a checksum other tools can reproduce.
+type ManifestSummary struct {
+	Filename string
+	Entries  int
+	Sliced   int
+	Skipped  int
+}
+
+// ExportManifest Writes a checksum manifest of every full hashed file, it must
+// run before the run summary prunes unique files from the session.
+func (app *App) ExportManifest() ManifestSummary {
+	summary := ManifestSummary{Filename: app.Flags.ManifestFile}
+	if summary.Filename == "" {
+		return summary
+	}
+
+	digests := append([]algorithms.Algorithm{app.Flags.PrimaryAlgorithm()}, app.Flags.DigestAlgorithms()...)
+	entries, sliced, err := app.manifestEntries(digests)
+	summary.Sliced = sliced
+	if err == nil {
+		err = writeManifest(summary.Filename, manifest.Format(app.Flags.ManifestFormat), digests, entries, &summary)
+	}
+	if err != nil {
+		app.Logger.Error("failed to export manifest", "path", summary.Filename, "error", err)
+		summary.Filename = ""
+	}
+	return summary
+}
+
+func (app *App) manifestEntries(digests []algorithms.Algorithm) ([]manifest.Entry, int, error) {
+	var entries []manifest.Entry
+	sliced := 0
+
+	app.Session.Dupes.Range(func(hash string, dupes *DuplicateFiles) bool {
+		for _, file := range dupes.Files {
+			if !file.FullHash {
+				sliced++
+				continue
+			}
+			entries = append(entries, manifestEntry(file, digests))
+		}
+		return true
+	})
+
+	// empty files are never read, but their checksum is the digest of nothing
+	emptyDigests := make(map[algorithms.Algorithm]string, len(digests))
+	for _, algorithm := range digests {
+		h, err := algorithm.New()
+		if err != nil {
+			return nil, sliced, err
+		}
+		emptyDigests[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	for _, file := range app.Session.Empty.Files {
+		entries = append(entries, manifest.Entry{
+			Path:    filepath.Join(file.Location, file.Path),
+			Digests: emptyDigests,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, sliced, nil
+}
+
+func manifestEntry(file File, digests []algorithms.Algorithm) manifest.Entry {
+	entry := manifest.Entry{
+		Path: filepath.Join(file.Location, file.Path),
+		// #nosec G115 -- file sizes are well within int64
+		Size:    int64(file.FileSize),
+		Digests: make(map[algorithms.Algorithm]string, len(digests)),
+	}
+	if len(file.Digests) == 0 {
+		entry.Digests[digests[0]] = file.Hash
+		return entry
+	}
+	for name, digest := range file.Digests {
+		if algorithm, ok := algorithms.Parse(name); ok {
+			entry.Digests[algorithm] = digest
+		}
+	}
+	return entry
+}
+
+func writeManifest(filename string, format manifest.Format, digests []algorithms.Algorithm, entries []manifest.Entry, summary *ManifestSummary) error {
+	fs, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	defer fs.Close()
+
+	invokedFrom, _ := os.Getwd()
+	mw := manifest.NewWriter(fs, format, digests)
+	if err := mw.WriteHeader(invokedFrom, strings.Join(os.Args, " ")); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := mw.Write(entry); err != nil {
+			if errors.Is(err, manifest.ErrUnsupportedPath) {
+				summary.Skipped++
+				continue
+			}
+			return err
+		}
+		summary.Entries++
+	}
+	return mw.Flush()
+}
@@ -0,0 +1,47 @@
+package smash
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/manifest"
+)
+
+func TestManifestEntry(t *testing.T) {
+	digests := []algorithms.Algorithm{algorithms.Sha256, algorithms.Md5}
+	tests := []struct {
+		name     string
+		file     File
+		expected manifest.Entry
+	}{
+		{
+			name: "primary hash only",
+			file: File{Location: "/photos", Path: "DSC19841.ARW", Hash: "abcd", FileSize: 4},
+			expected: manifest.Entry{
+				Path:    "/photos/DSC19841.ARW",
+				Size:    4,
+				Digests: map[algorithms.Algorithm]string{algorithms.Sha256: "abcd"},
+			},
+		},
+		{
+			name: "additional digests",
+			file: File{Location: "/photos", Path: "DSC19841.ARW", Hash: "abcd", FileSize: 4,
+				Digests: map[string]string{"sha-256": "abcd", "md5": "ef01"}},
+			expected: manifest.Entry{
+				Path:    "/photos/DSC19841.ARW",
+				Size:    4,
+				Digests: map[algorithms.Algorithm]string{algorithms.Sha256: "abcd", algorithms.Md5: "ef01"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := manifestEntry(tt.file, digests)
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package smash
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/thushan/smash/pkg/nerdstats"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+// Metrics are exported for Prometheus on --metrics-addr, a nil *Metrics records nothing.
+// MetricsJob is the Pushgateway job --metrics-push pushes to.
+const MetricsJob = "smash"
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	filesIndexed    prometheus.Counter
+	filesHashed     prometheus.Counter
+	fileFails       prometheus.Counter
+	bytesRead       prometheus.Counter
+	hashDuration    prometheus.Histogram
+	duplicateGroups prometheus.Gauge
+	duplicateFiles  prometheus.Gauge
+	reclaimable     prometheus.Gauge
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		filesIndexed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "smash_files_indexed_total",
+			Help: "Files found by the indexer & queued for smashing.",
+		}),
+		filesHashed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "smash_files_hashed_total",
+			Help: "Files hashed, excludes those ignored by size or that failed.",
+		}),
+		fileFails: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "smash_file_fails_total",
+			Help: "Files & locations that couldn't be read.",
+		}),
+		bytesRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "smash_bytes_read_total",
+			Help: "Bytes read while hashing, sliced files only read their slices.",
+		}),
+		hashDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "smash_hash_duration_seconds",
+			Help:    "Time taken to hash a file.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+		}),
+		duplicateGroups: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "smash_duplicate_groups",
+			Help: "Groups of duplicate files found by the last scan.",
+		}),
+		duplicateFiles: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "smash_duplicate_files",
+			Help: "Duplicate files found by the last scan, excluding the first of each group.",
+		}),
+		reclaimable: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "smash_reclaimable_bytes",
+			Help: "Bytes that could be reclaimed by removing the duplicates found by the last scan.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.filesIndexed, m.filesHashed, m.fileFails, m.bytesRead, m.hashDuration,
+		m.duplicateGroups, m.duplicateFiles, m.reclaimable,
+	)
+	m.registerNerdStats()
+	return m
+}
+
+// registerNerdStats Exports the same runtime stats as --nerd-stats, read on every scrape.
+func (m *Metrics) registerNerdStats() {
+	gauges := []struct {
+		name  string
+		help  string
+		value func(nerdstats.NerdStats) float64
+	}{
+		{"smash_memory_sys_bytes", "Memory obtained from the OS.", func(s nerdstats.NerdStats) float64 { return float64(s.Sys) }},
+		{"smash_memory_alloc_bytes", "Memory allocated & still in use.", func(s nerdstats.NerdStats) float64 { return float64(s.Allocations) }},
+		{"smash_memory_alloc_bytes_total", "Memory allocated, including freed.", func(s nerdstats.NerdStats) float64 { return float64(s.TotalAllocations) }},
+		{"smash_memory_mallocs_total", "Heap objects allocated.", func(s nerdstats.NerdStats) float64 { return float64(s.Mallocs) }},
+		{"smash_memory_frees_total", "Heap objects freed.", func(s nerdstats.NerdStats) float64 { return float64(s.Frees) }},
+		{"smash_memory_live_objects", "Heap objects still in use.", func(s nerdstats.NerdStats) float64 { return float64(s.LiveObjects) }},
+		{"smash_gc_pause_seconds_total", "Time spent paused for garbage collection.", func(s nerdstats.NerdStats) float64 { return float64(s.GcPauseTotalNs) / float64(time.Second) }},
+		{"smash_gc_cycles_total", "Completed garbage collection cycles.", func(s nerdstats.NerdStats) float64 { return float64(s.CompletedGcCycles) }},
+		{"smash_goroutines", "Active goroutines.", func(s nerdstats.NerdStats) float64 { return float64(s.GoRoutines) }},
+	}
+	for _, gauge := range gauges {
+		value := gauge.value
+		m.registry.MustRegister(prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{Name: gauge.name, Help: gauge.help},
+			func() float64 { return value(nerdstats.Snapshot()) },
+		))
+	}
+}
+
+// Handler Returns the Prometheus scrape endpoint.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve Listens on the address before returning so a port in use fails the run, then serves /metrics
+// in the background until the returned func shuts the server down.
+func (m *Metrics) Serve(address string, logger *slog.Logger) (func(), error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("metrics server stopped", "error", err)
+		}
+	}()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+		<-done
+	}, nil
+}
+
+// Push Sends the metrics to a Prometheus Pushgateway under the smash job, replacing what it last pushed.
+func (m *Metrics) Push(url string) error {
+	return push.New(url, MetricsJob).Gatherer(m.registry).Push()
+}
+
+func (m *Metrics) observeIndexed() {
+	if m == nil {
+		return
+	}
+	m.filesIndexed.Inc()
+}
+
+func (m *Metrics) observeFail() {
+	if m == nil {
+		return
+	}
+	m.fileFails.Inc()
+}
+
+func (m *Metrics) observeHashed(stats slicer.SlicerStats, elapsedMs int64) {
+	if m == nil {
+		return
+	}
+	m.filesHashed.Inc()
+	m.bytesRead.Add(float64(stats.BytesRead))
+	m.hashDuration.Observe(float64(elapsedMs) / 1000)
+}
+
+// observeDupes Sets the duplicate gauges from the session, smash watch calls it
+// as files change so the gauges don't wait for the run to end.
+func (m *Metrics) observeDupes(dupes *xsync.Map[string, *DuplicateFiles]) {
+	if m == nil {
+		return
+	}
+	groups, files, reclaimable := 0, 0, uint64(0)
+	dupes.Range(func(_ string, df *DuplicateFiles) bool {
+		df.RLock()
+		if duplicates := len(df.Files) - 1; duplicates > 0 {
+			groups++
+			files += duplicates
+			reclaimable += df.Files[0].FileSize * uint64(duplicates)
+		}
+		df.RUnlock()
+		return true
+	})
+	m.duplicateGroups.Set(float64(groups))
+	m.duplicateFiles.Set(float64(files))
+	m.reclaimable.Set(float64(reclaimable))
+}
@@ -0,0 +1,116 @@
+package smash
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.observeIndexed()
+	metrics.observeIndexed()
+	metrics.observeFail()
+	metrics.observeHashed(slicer.SlicerStats{BytesRead: 1984}, 250)
+
+	dupes := xsync.NewMap[string, *DuplicateFiles]()
+	dupes.Store("a", &DuplicateFiles{Files: []File{{FileSize: 1024}, {FileSize: 1024}, {FileSize: 1024}}})
+	dupes.Store("b", &DuplicateFiles{Files: []File{{FileSize: 2048}, {FileSize: 2048}}})
+	dupes.Store("c", &DuplicateFiles{Files: []File{{FileSize: 4096}}})
+	metrics.observeDupes(dupes)
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"smash_files_indexed_total 2",
+		"smash_files_hashed_total 1",
+		"smash_file_fails_total 1",
+		"smash_bytes_read_total 1984",
+		`smash_hash_duration_seconds_bucket{le="0.256"} 1`,
+		"smash_hash_duration_seconds_count 1",
+		"smash_duplicate_groups 2",
+		"smash_duplicate_files 3",
+		"smash_reclaimable_bytes 4096",
+		"smash_goroutines ",
+	}
+	for _, metric := range expected {
+		if !strings.Contains(string(body), metric) {
+			t.Errorf("expected %q in metrics, got %s", metric, body)
+		}
+	}
+}
+
+func TestMetricsNilRecordsNothing(t *testing.T) {
+	var metrics *Metrics
+	metrics.observeIndexed()
+	metrics.observeFail()
+	metrics.observeHashed(slicer.SlicerStats{}, 0)
+	metrics.observeDupes(xsync.NewMap[string, *DuplicateFiles]())
+}
+
+func TestMetricsServeStops(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := free.Addr().String()
+	_ = free.Close()
+
+	stop, err := NewMetrics().Serve(address, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	if _, err := http.Get("http://" + address + "/metrics"); err == nil {
+		t.Errorf("expected metrics on %s to stop being served", address)
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("expected %s to be released, got %v", address, err)
+	}
+	_ = listener.Close()
+}
+
+func TestMetricsPush(t *testing.T) {
+	var pushed string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		pushed = r.Method + " " + r.URL.Path + " " + string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	metrics := NewMetrics()
+	dupes := xsync.NewMap[string, *DuplicateFiles]()
+	dupes.Store("a", &DuplicateFiles{Files: []File{{FileSize: 1024}, {FileSize: 1024}}})
+	metrics.observeDupes(dupes)
+
+	if err := metrics.Push(gateway.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(pushed, http.MethodPut+" /metrics/job/"+MetricsJob) {
+		t.Errorf("expected a PUT to the %s job, got %q", MetricsJob, pushed)
+	}
+	if !strings.Contains(pushed, "smash_reclaimable_bytes") {
+		t.Errorf("expected the final gauges to be pushed, got %q", pushed)
+	}
+}
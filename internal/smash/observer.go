@@ -0,0 +1,135 @@
+package smash
+
+import (
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+// Stage is a step of a run, observers are told as each starts & finishes.
+type Stage int
+
+const (
+	StageIndexing Stage = iota
+	StageSmashing
+	StageRetrying
+	StageFinalising
+)
+
+// Observer is told about a run as it happens, the console output & reports
+// subscribe to it as can any embedding code via App.Observers. All but
+// ScanFinished are called from the workers so must be safe for concurrent use
+// and quick, a slow observer slows smashing down.
+type Observer interface {
+	// StageStarted is called as each stage of the run starts
+	StageStarted(stage Stage)
+	// StageFinished is called once a stage is done, stopped if the run was cancelled during it
+	StageFinished(stage Stage, stopped bool)
+	// LocationIndexing is called as the indexer moves on to each location
+	LocationIndexing(name string)
+	// FileIndexed is called as a file is taken off the queue, before it's smashed
+	FileIndexed(file *indexer.FileFS)
+	// FileSmashed is called with every file once it's been hashed, including empty files
+	FileSmashed(file File)
+	// FileFailed is called with every file or location that couldn't be read
+	FileFailed(name string, err error)
+	// GroupFormed is called once for every group of duplicates when smashing is
+	// done, before ScanFinished. Files joining a group as it happens are in FileSmashed
+	GroupFormed(hash string, files []File)
+	// ScanFinished is called once the run has been summarised
+	ScanFinished(summary *RunSummary)
+}
+
+// NopObserver Ignores everything, embed it to observe only some events.
+type NopObserver struct{}
+
+func (NopObserver) StageStarted(Stage)          {}
+func (NopObserver) StageFinished(Stage, bool)   {}
+func (NopObserver) LocationIndexing(string)     {}
+func (NopObserver) FileIndexed(*indexer.FileFS) {}
+func (NopObserver) FileSmashed(File)            {}
+func (NopObserver) FileFailed(string, error)    {}
+func (NopObserver) GroupFormed(string, []File)  {}
+func (NopObserver) ScanFinished(*RunSummary)    {}
+
+// reportObserver Exports the report once the run is summarised.
+type reportObserver struct {
+	NopObserver
+	app *App
+}
+
+func (r *reportObserver) ScanFinished(*RunSummary) {
+	r.app.ExportReport()
+}
+
+// subscribe Puts the report ahead of the app's observers, so it's exported
+// before the console prints the summary that names it.
+func (app *App) subscribe() {
+	if len(app.Observers) > 0 {
+		if _, ok := app.Observers[0].(*reportObserver); ok {
+			return
+		}
+	}
+	app.Observers = append([]Observer{&reportObserver{app: app}}, app.Observers...)
+}
+
+func (app *App) stageStarted(stage Stage) {
+	for _, o := range app.Observers {
+		o.StageStarted(stage)
+	}
+}
+
+func (app *App) stageFinished(stage Stage, stopped bool) {
+	for _, o := range app.Observers {
+		o.StageFinished(stage, stopped)
+	}
+}
+
+func (app *App) locationIndexing(name string) {
+	for _, o := range app.Observers {
+		o.LocationIndexing(name)
+	}
+}
+
+func (app *App) fileIndexed(file *indexer.FileFS) {
+	for _, o := range app.Observers {
+		o.FileIndexed(file)
+	}
+}
+
+// fileSmashed Checkpoints the file then tells the observers about it.
+func (app *App) fileSmashed(file File) {
+	app.Runtime.Checkpoint.Record(file)
+	app.notifySmashed(file)
+}
+
+// notifySmashed Tells the observers about a file added to the session.
+func (app *App) notifySmashed(file File) {
+	for _, o := range app.Observers {
+		o.FileSmashed(file)
+	}
+}
+
+// groupsFormed Tells the observers about every group of duplicates, once the
+// session is complete so each group is passed once with all of its files.
+func (app *App) groupsFormed() {
+	app.Session.Dupes.Range(func(hash string, dupes *DuplicateFiles) bool {
+		if len(dupes.Files) < 2 {
+			return true
+		}
+		for _, o := range app.Observers {
+			o.GroupFormed(hash, dupes.Files)
+		}
+		return true
+	})
+}
+
+func (app *App) fileFailed(name string, err error) {
+	for _, o := range app.Observers {
+		o.FileFailed(name, err)
+	}
+}
+
+func (app *App) scanFinished() {
+	for _, o := range app.Observers {
+		o.ScanFinished(app.Summary)
+	}
+}
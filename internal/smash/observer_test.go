@@ -0,0 +1,140 @@
+package smash
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+type recordingObserver struct {
+	groups    map[string]int
+	summary   *RunSummary
+	started   []Stage
+	locations []string
+	stopped   int
+	stages    int
+	indexed   int
+	smashed   int
+	formed    int
+	failed    int
+	finished  int
+	sync.Mutex
+}
+
+func (r *recordingObserver) StageStarted(stage Stage) {
+	r.Lock()
+	defer r.Unlock()
+	r.started = append(r.started, stage)
+}
+func (r *recordingObserver) StageFinished(_ Stage, stopped bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.stages++
+	if stopped {
+		r.stopped++
+	}
+}
+func (r *recordingObserver) LocationIndexing(name string) {
+	r.Lock()
+	defer r.Unlock()
+	r.locations = append(r.locations, name)
+}
+func (r *recordingObserver) FileIndexed(*indexer.FileFS) {
+	r.Lock()
+	defer r.Unlock()
+	r.indexed++
+}
+func (r *recordingObserver) FileSmashed(File) {
+	r.Lock()
+	defer r.Unlock()
+	r.smashed++
+}
+func (r *recordingObserver) FileFailed(string, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.failed++
+}
+func (r *recordingObserver) GroupFormed(hash string, files []File) {
+	r.Lock()
+	defer r.Unlock()
+	r.groups[hash] = len(files)
+	r.formed++
+}
+func (r *recordingObserver) ScanFinished(summary *RunSummary) {
+	r.finished++
+	r.summary = summary
+}
+
+func TestAppObservers(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{
+		"DSC19841.ARW": "smash",
+		"DSC19842.ARW": "smash",
+		"DSC19843.ARW": "smash",
+		"unique.txt":   "unique",
+		"empty.txt":    "",
+	} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	observer := &recordingObserver{groups: map[string]int{}}
+	app := &App{
+		Flags: &Flags{
+			Algorithms:     []int{int(algorithms.Xxhash)},
+			MaxWorkers:     2,
+			MaxThreads:     2,
+			SliceSize:      8192,
+			SliceThreshold: 102400,
+			Slices:         4,
+			ShowTop:        10,
+			ProgressUpdate: 5,
+			Silent:         true,
+			HideOutput:     true,
+		},
+		Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, os.DirFS(tempDir))},
+		Observers: []Observer{observer},
+	}
+
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	app.fileFailed("/mnt/c/pagefile.sys", errors.New("locked"))
+
+	if !slices.Equal(observer.started, []Stage{StageIndexing, StageSmashing, StageFinalising}) {
+		t.Errorf("expected indexing, smashing & finalising to start, got %v", observer.started)
+	}
+	if observer.stages != 3 || observer.stopped != 0 {
+		t.Errorf("expected 3 stages to finish, got %d (%d stopped)", observer.stages, observer.stopped)
+	}
+	if !slices.Equal(observer.locations, []string{tempDir}) {
+		t.Errorf("expected %s to be indexed, got %v", tempDir, observer.locations)
+	}
+	if observer.indexed != 5 {
+		t.Errorf("expected 5 files indexed, got %d", observer.indexed)
+	}
+	if observer.smashed != 5 {
+		t.Errorf("expected 5 files smashed, got %d", observer.smashed)
+	}
+	if observer.failed != 1 {
+		t.Errorf("expected 1 file failed, got %d", observer.failed)
+	}
+	if len(observer.groups) != 1 || observer.formed != 1 {
+		t.Fatalf("expected 1 group formed once, got %v formed %d times", observer.groups, observer.formed)
+	}
+	for hash, files := range observer.groups {
+		if files != 3 {
+			t.Errorf("expected group %s to have 3 files, got %d", hash, files)
+		}
+	}
+	if observer.finished != 1 || observer.summary != app.Summary {
+		t.Errorf("expected the summary once, got %d", observer.finished)
+	}
+}
@@ -0,0 +1,84 @@
+package smash
+
+import (
+	"cmp"
+	"maps"
+	"path/filepath"
+	"slices"
+)
+
+// DirectoryOverlap Is a pair of directories holding copies of the same files,
+// Size is the space reclaimed by keeping one copy of each of the Files.
+type DirectoryOverlap struct {
+	DirA  string
+	DirB  string
+	Size  uint64
+	Files int64
+}
+
+// directoryOverlaps Aggregates duplicate groups into the pairs of directories
+// they have copies in. A group counts once, for the two directories with the
+// most copies of it, so a file copied into many directories is one pair not all of them.
+type directoryOverlaps map[[2]string]*DirectoryOverlap
+
+func (do directoryOverlaps) add(files []File) {
+	// empty files take no space to reclaim, they're only noise
+	if len(files) < 2 || files[0].FileSize == 0 {
+		return
+	}
+	copies := make(map[string]int64, len(files))
+	for _, file := range files {
+		copies[filepath.Join(file.Location, filepath.Dir(file.Path))]++
+	}
+	if len(copies) < 2 {
+		return
+	}
+	dirs := slices.Collect(maps.Keys(copies))
+	slices.SortFunc(dirs, func(a, b string) int {
+		return cmp.Or(cmp.Compare(copies[b], copies[a]), cmp.Compare(a, b))
+	})
+	pair := [2]string{min(dirs[0], dirs[1]), max(dirs[0], dirs[1])}
+
+	overlap, ok := do[pair]
+	if !ok {
+		overlap = &DirectoryOverlap{DirA: pair[0], DirB: pair[1]}
+		do[pair] = overlap
+	}
+	shared := copies[dirs[0]] + copies[dirs[1]]
+	overlap.Files += shared
+	// #nosec G115 -- shared is at least 2
+	overlap.Size += files[0].FileSize * uint64(shared-1)
+}
+
+// top Returns up to n overlaps, largest reclaimable first.
+func (do directoryOverlaps) top(n int) []DirectoryOverlap {
+	overlaps := make([]DirectoryOverlap, 0, len(do))
+	for _, overlap := range do {
+		overlaps = append(overlaps, *overlap)
+	}
+	slices.SortFunc(overlaps, func(a, b DirectoryOverlap) int {
+		return cmp.Or(
+			cmp.Compare(b.Size, a.Size),
+			cmp.Compare(b.Files, a.Files),
+			cmp.Compare(a.DirA, b.DirA),
+			cmp.Compare(a.DirB, b.DirB),
+		)
+	})
+	return overlaps[:min(len(overlaps), n)]
+}
+
+func summariseDirectoryOverlaps(overlaps []DirectoryOverlap) []ReportOverlapSummary {
+	if len(overlaps) == 0 {
+		return nil
+	}
+	summary := make([]ReportOverlapSummary, len(overlaps))
+	for i, overlap := range overlaps {
+		summary[i] = ReportOverlapSummary{
+			DirA:  absolutePath(overlap.DirA),
+			DirB:  absolutePath(overlap.DirB),
+			Files: overlap.Files,
+			Size:  overlap.Size,
+		}
+	}
+	return summary
+}
@@ -0,0 +1,54 @@
+package smash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDirectoryOverlaps(t *testing.T) {
+	file := func(location string, path string, size uint64) File {
+		return File{Location: location, Path: path, FileSize: size}
+	}
+	overlaps := make(directoryOverlaps)
+	overlaps.add([]File{file("/photos", "2024/a.jpg", 100), file("/backup", "2024/a.jpg", 100)})
+	overlaps.add([]File{file("/photos", "2024/b.jpg", 200), file("/backup", "2024/b.jpg", 200), file("/backup", "2024/c.jpg", 200)})
+	overlaps.add([]File{file("/photos", "2024/d.raw", 1000), file("/old", "d.raw", 1000), file("/backup", "2024/d.raw", 1000), file("/photos", "2024/d copy.raw", 1000)})
+	overlaps.add([]File{file("/photos", "2024/e.jpg", 10), file("/photos", "2024/f.jpg", 10)})
+	overlaps.add([]File{file("/photos", "2024/.keep", 0), file("/backup", "2024/.keep", 0)})
+
+	expected := []DirectoryOverlap{
+		{DirA: "/backup/2024", DirB: "/photos/2024", Size: 2500, Files: 8},
+	}
+	if actual := overlaps.top(10); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestDirectoryOverlapsCountsGroupsOnce(t *testing.T) {
+	overlaps := make(directoryOverlaps)
+	var files []File
+	for _, dir := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		files = append(files, File{Location: dir, Path: "smash.iso", FileSize: 100})
+	}
+	overlaps.add(files)
+	overlaps.add([]File{{Location: "/x", Path: "a.jpg", FileSize: 10}, {Location: "/y", Path: "a.jpg", FileSize: 10}})
+
+	expected := []DirectoryOverlap{{DirA: "/a", DirB: "/b", Size: 100, Files: 2}}
+	if actual := overlaps.top(1); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if len(overlaps) != 2 {
+		t.Errorf("expected 2 pairs, got %d", len(overlaps))
+	}
+}
+
+func TestSummariseDirectoryOverlaps(t *testing.T) {
+	if summary := summariseDirectoryOverlaps(nil); summary != nil {
+		t.Errorf("expected no overlaps, got %v", summary)
+	}
+	summary := summariseDirectoryOverlaps([]DirectoryOverlap{{DirA: "/backup", DirB: "/photos", Size: 100, Files: 1}})
+	expected := []ReportOverlapSummary{{DirA: absolutePath("/backup"), DirB: absolutePath("/photos"), Size: 100, Files: 1}}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("expected %v, got %v", expected, summary)
+	}
+}
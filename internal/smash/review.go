@@ -0,0 +1,266 @@
+package smash
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ReviewAction Says what to do with a duplicate once a review is applied.
+type ReviewAction string
+
+const (
+	// ReviewKeep leaves the file alone, every group must keep at least one file
+	ReviewKeep ReviewAction = "keep"
+	// ReviewDelete removes the file
+	ReviewDelete ReviewAction = "delete"
+	// ReviewLink replaces the file with a hard link to a kept copy
+	ReviewLink ReviewAction = "link"
+)
+
+var ReviewActions = []ReviewAction{ReviewKeep, ReviewDelete, ReviewLink}
+
+// ErrChangedSinceScan is a file that no longer matches the report, it's left alone.
+var ErrChangedSinceScan = errors.New("changed since it was smashed")
+
+// ErrNotDuplicate is a file whose content differs from the copy kept, sliced hashes only
+// sample a file so it's left alone.
+var ErrNotDuplicate = errors.New("isn't a byte for byte copy of the file kept")
+
+// Review Holds the duplicates of a report & what to do with each file, largest
+// reclaimable groups first. Every file is kept until it's marked otherwise.
+type Review struct {
+	Groups []*ReviewGroup
+}
+type ReviewGroup struct {
+	Actions []ReviewAction
+	ReportDuplicateSummary
+}
+
+// ReviewPlan Lists the files to delete or link, in the order they're applied.
+type ReviewPlan struct {
+	Steps       []ReviewStep
+	Reclaimable uint64
+}
+type ReviewStep struct {
+	Keep   ReportFileSummary
+	File   ReportFileSummary
+	Action ReviewAction
+}
+type ReviewResult struct {
+	Err  error
+	Step ReviewStep
+}
+
+// ReadReport Reads a report exported by smash for review.
+func ReadReport(path string) (ReportOutput, error) {
+	var report ReportOutput
+	fs, err := os.Open(path)
+	if err != nil {
+		return report, err
+	}
+	defer fs.Close()
+	if err := json.NewDecoder(fs).Decode(&report); err != nil {
+		return report, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+func NewReview(report ReportOutput) *Review {
+	review := &Review{Groups: make([]*ReviewGroup, 0, len(report.Analysis.Dupes))}
+	for _, dupe := range report.Analysis.Dupes {
+		if len(dupe.Files) < 2 {
+			continue
+		}
+		actions := make([]ReviewAction, len(dupe.Files))
+		for i := range actions {
+			actions[i] = ReviewKeep
+		}
+		review.Groups = append(review.Groups, &ReviewGroup{ReportDuplicateSummary: dupe, Actions: actions})
+	}
+	slices.SortStableFunc(review.Groups, func(a, b *ReviewGroup) int {
+		if a.Reclaimable() != b.Reclaimable() {
+			return cmp.Compare(b.Reclaimable(), a.Reclaimable())
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return review
+}
+
+// Reclaimable Returns the space freed if all but one file in the group went.
+func (g *ReviewGroup) Reclaimable() uint64 {
+	// #nosec G115 -- a group always has at least one file
+	return g.Size * uint64(len(g.Files)-1)
+}
+
+// Marked Returns the number of files marked to delete or link.
+func (g *ReviewGroup) Marked() int {
+	marked := 0
+	for _, action := range g.Actions {
+		if action != ReviewKeep {
+			marked++
+		}
+	}
+	return marked
+}
+
+// Mark Sets the action for every file in the directory (or below it), returning how many were marked.
+func (r *Review) Mark(dir string, action ReviewAction) int {
+	dir = filepath.Clean(dir)
+	marked := 0
+	for _, group := range r.Groups {
+		for i, file := range group.Files {
+			if inDirectory(file.Path, dir) {
+				group.Actions[i] = action
+				marked++
+			}
+		}
+	}
+	return marked
+}
+
+func inDirectory(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Plan Returns the steps to apply the review, failing if a group would lose every copy.
+func (r *Review) Plan() (ReviewPlan, error) {
+	var plan ReviewPlan
+	for _, group := range r.Groups {
+		if group.Marked() == 0 {
+			continue
+		}
+		keep := slices.Index(group.Actions, ReviewKeep)
+		if keep < 0 {
+			return ReviewPlan{}, fmt.Errorf("every file in group %s is marked, keep at least one (%s)", group.ID, group.Files[0].Path)
+		}
+		for i, action := range group.Actions {
+			if action == ReviewKeep {
+				continue
+			}
+			plan.Steps = append(plan.Steps, ReviewStep{Action: action, File: group.Files[i], Keep: group.Files[keep]})
+			plan.Reclaimable += group.Size
+		}
+	}
+	return plan, nil
+}
+
+// Count Returns the number of steps with the action.
+func (p ReviewPlan) Count(action ReviewAction) int {
+	count := 0
+	for _, step := range p.Steps {
+		if step.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Apply Deletes & links the files in the plan. Both the file and the copy kept
+// must still match the report & each other byte for byte, anything else is left alone.
+func (p ReviewPlan) Apply() []ReviewResult {
+	results := make([]ReviewResult, len(p.Steps))
+	for i, step := range p.Steps {
+		results[i] = ReviewResult{Step: step, Err: applyStep(step)}
+	}
+	return results
+}
+
+func applyStep(step ReviewStep) error {
+	keep, err := unchangedSinceScan(step.Keep)
+	if err != nil {
+		return fmt.Errorf("copy kept %s %w", step.Keep.Path, err)
+	}
+	file, err := unchangedSinceScan(step.File)
+	if err != nil {
+		return err
+	}
+
+	if os.SameFile(keep, file) {
+		if step.Action == ReviewLink {
+			return nil
+		}
+		// deleting one link of the file kept is safe, its content is still there
+	} else if same, err := sameContent(step.Keep.Path, step.File.Path); err != nil {
+		return err
+	} else if !same {
+		return ErrNotDuplicate
+	}
+
+	switch step.Action {
+	case ReviewDelete:
+		return os.Remove(step.File.Path)
+	case ReviewLink:
+		return replaceWithLink(step.Keep.Path, step.File.Path)
+	}
+	return fmt.Errorf("unsupported review action %q", step.Action)
+}
+
+// sameContent Compares two files byte for byte.
+func sameContent(pathA string, pathB string) (bool, error) {
+	fa, err := os.Open(pathA)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(pathB)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, 64*1024)
+	bufB := make([]byte, len(bufA))
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		doneA := errors.Is(errA, io.EOF) || errors.Is(errA, io.ErrUnexpectedEOF)
+		doneB := errors.Is(errB, io.EOF) || errors.Is(errB, io.ErrUnexpectedEOF)
+		switch {
+		case errA != nil && !doneA:
+			return false, errA
+		case errB != nil && !doneB:
+			return false, errB
+		case doneA || doneB:
+			return doneA == doneB, nil
+		}
+	}
+}
+
+// replaceWithLink Links next to the file first then renames over it, so the
+// file is never missing if linking fails (ie. across file systems).
+func replaceWithLink(keep string, path string) error {
+	temp := path + ".smash-link"
+	if err := os.Link(keep, temp); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		_ = os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+func unchangedSinceScan(file ReportFileSummary) (os.FileInfo, error) {
+	info, err := os.Lstat(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G115 -- file sizes are well within int64
+	if !info.Mode().IsRegular() || info.Size() != int64(file.Size) ||
+		(!file.ModTime.IsZero() && !info.ModTime().Equal(file.ModTime)) {
+		return nil, ErrChangedSinceScan
+	}
+	return info, nil
+}
@@ -0,0 +1,206 @@
+package smash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+// reviewReport Writes each group of files with the same content & returns a report of them.
+func reviewReport(t *testing.T, dir string, groups ...[]string) ReportOutput {
+	t.Helper()
+	var report ReportOutput
+	for _, names := range groups {
+		content := []byte(names[0] + " smash")
+		dupe := ReportDuplicateSummary{ID: names[0], Hash: names[0]}
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dupe.Size = uint64(info.Size())
+			dupe.Files = append(dupe.Files, ReportFileSummary{
+				ReportFileBaseSummary: ReportFileBaseSummary{Filename: filepath.Base(name), Path: path, ModTime: info.ModTime()},
+				Hash:                  names[0],
+				Size:                  uint64(info.Size()),
+			})
+		}
+		report.Analysis.Dupes = append(report.Analysis.Dupes, dupe)
+	}
+	return report
+}
+
+func TestNewReview_SortsByReclaimable(t *testing.T) {
+	dir := t.TempDir()
+	report := reviewReport(t, dir,
+		[]string{"a.jpg", "backup/a.jpg"},
+		[]string{"b.jpg", "backup/b.jpg", "old/b.jpg"},
+	)
+	review := NewReview(report)
+
+	if len(review.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(review.Groups))
+	}
+	if review.Groups[0].ID != "b.jpg" {
+		t.Errorf("expected b.jpg first, got %s", review.Groups[0].ID)
+	}
+	for _, group := range review.Groups {
+		if group.Marked() != 0 {
+			t.Errorf("expected every file kept, got %v", group.Actions)
+		}
+	}
+}
+
+func TestReview_Mark(t *testing.T) {
+	dir := t.TempDir()
+	review := NewReview(reviewReport(t, dir,
+		[]string{"a.jpg", "backup/a.jpg"},
+		[]string{"b.jpg", "backup/b.jpg", "backup-old/b.jpg"},
+	))
+
+	if marked := review.Mark(filepath.Join(dir, "backup"), ReviewDelete); marked != 2 {
+		t.Errorf("expected 2 marked, got %d", marked)
+	}
+	plan, err := review.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Count(ReviewDelete) != 2 {
+		t.Errorf("expected 2 deletes, got %d", plan.Count(ReviewDelete))
+	}
+	for _, step := range plan.Steps {
+		if filepath.Dir(step.File.Path) != filepath.Join(dir, "backup") {
+			t.Errorf("expected only files in backup, got %s", step.File.Path)
+		}
+		if step.Keep.Path != filepath.Join(dir, step.File.Filename) {
+			t.Errorf("expected %s kept, got %s", step.File.Filename, step.Keep.Path)
+		}
+	}
+}
+
+func TestReview_PlanKeepsOneCopy(t *testing.T) {
+	dir := t.TempDir()
+	review := NewReview(reviewReport(t, dir, []string{"a.jpg", "backup/a.jpg"}))
+	review.Mark(dir, ReviewDelete)
+
+	if _, err := review.Plan(); err == nil {
+		t.Error("expected an error when every copy is marked")
+	}
+}
+
+func TestReviewPlan_Apply(t *testing.T) {
+	dir := t.TempDir()
+	review := NewReview(reviewReport(t, dir,
+		[]string{"a.jpg", "backup/a.jpg"},
+		[]string{"b.jpg", "backup/b.jpg"},
+		[]string{"c.jpg", "backup/c.jpg"},
+	))
+	for _, group := range review.Groups {
+		switch group.ID {
+		case "a.jpg":
+			group.Actions[1] = ReviewDelete
+		case "b.jpg":
+			group.Actions[1] = ReviewLink
+		case "c.jpg":
+			group.Actions[1] = ReviewDelete
+		}
+	}
+	plan, err := review.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Changed after the report, so it must be left alone
+	changed := filepath.Join(dir, "backup", "c.jpg")
+	if err := os.WriteFile(changed, []byte("smash hits"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range plan.Apply() {
+		switch result.Step.File.Path {
+		case changed:
+			if !errors.Is(result.Err, ErrChangedSinceScan) {
+				t.Errorf("expected %v, got %v", ErrChangedSinceScan, result.Err)
+			}
+		default:
+			if result.Err != nil {
+				t.Errorf("expected %s applied, got %v", result.Step.File.Path, result.Err)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "backup", "a.jpg")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected backup/a.jpg deleted, got %v", err)
+	}
+	if _, err := os.Stat(changed); err != nil {
+		t.Errorf("expected backup/c.jpg left alone, got %v", err)
+	}
+	kept, _ := os.Stat(filepath.Join(dir, "b.jpg"))
+	linked, err := os.Stat(filepath.Join(dir, "backup", "b.jpg"))
+	if err != nil || !os.SameFile(kept, linked) {
+		t.Errorf("expected backup/b.jpg linked to b.jpg, got %v", err)
+	}
+}
+
+func TestReviewPlan_ApplyKeepsSlicedLookalikes(t *testing.T) {
+	dir := t.TempDir()
+	report := reviewReport(t, dir, []string{"a.iso", "backup/a.iso"})
+
+	// Same slices, but a byte in between them differs
+	content := bytes.Repeat([]byte("smash"), 16*1024)
+	lookalike := bytes.Clone(content)
+	lookalike[5000] = 'S'
+	sl := slicer.NewConfigured(algorithms.Xxhash, 4, 1024, 8*1024)
+	var hashes [][]byte
+	for name, data := range map[string][]byte{"a.iso": content, "backup/a.iso": lookalike} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stats, err := sl.SliceFS(context.Background(), os.DirFS(dir), name, &slicer.Options{DisableAutoText: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, stats.Hash)
+	}
+	if !bytes.Equal(hashes[0], hashes[1]) {
+		t.Fatal("expected both files to slice to the same hash")
+	}
+	for i, file := range report.Analysis.Dupes[0].Files {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report.Analysis.Dupes[0].Files[i].Size = uint64(info.Size())
+		report.Analysis.Dupes[0].Files[i].ModTime = info.ModTime()
+	}
+
+	for _, action := range []ReviewAction{ReviewDelete, ReviewLink} {
+		review := NewReview(report)
+		review.Groups[0].Actions[1] = action
+		plan, err := review.Plan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range plan.Apply() {
+			if !errors.Is(result.Err, ErrNotDuplicate) {
+				t.Errorf("expected %v when asked to %s, got %v", ErrNotDuplicate, action, result.Err)
+			}
+		}
+		kept, err := os.ReadFile(filepath.Join(dir, "backup", "a.iso"))
+		if err != nil || !bytes.Equal(kept, lookalike) {
+			t.Errorf("expected backup/a.iso kept after %s, got %v", action, err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package smash
+
+import (
+	"cmp"
+	"context"
+	"path/filepath"
+	"slices"
+
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+// DeviceQueue feeds the files of every location on the same physical device
+// to a pool of workers dedicated to that device, so a slow disk can't starve
+// a fast one and each disk sees a sensible amount of concurrency.
+type DeviceQueue struct {
+	Files     chan *indexer.FileFS
+	Locations []indexer.LocationFS
+	Device    indexer.Device
+	Workers   int
+	// Ordered queues are read in inode order to minimise seeks on rotational disks
+	Ordered bool
+}
+
+// LocationLimits caps the number of concurrent reads per location.
+type LocationLimits map[string]chan struct{}
+
+// orderedBatch is how many files are sorted by inode at a time on rotational
+// disks, bounding memory on huge scans while still cutting most of the seeks.
+const orderedBatch = 4096
+
+// buildDeviceQueues Groups the locations by device, splitting --max-workers
+// between them. Rotational devices are read in inode order, with at most
+// --hdd-workers each when it's given.
+func (app *App) buildDeviceQueues() []*DeviceQueue {
+	f := app.Flags
+	var queues []*DeviceQueue
+	byDevice := make(map[uint64]*DeviceQueue)
+
+	for _, location := range app.Locations {
+		// Locations we can't place on a device share the unknown (zero) device
+		device := location.Device
+		queue, ok := byDevice[device.ID]
+		if !ok {
+			queue = &DeviceQueue{
+				Device:  device,
+				Files:   make(chan *indexer.FileFS),
+				Ordered: device.Rotational,
+			}
+			byDevice[device.ID] = queue
+			queues = append(queues, queue)
+		}
+		queue.Locations = append(queue.Locations, location)
+	}
+
+	for i, queue := range queues {
+		// the first queues take the remainder, every queue gets at least one worker
+		queue.Workers = f.MaxWorkers / len(queues)
+		if i < f.MaxWorkers%len(queues) {
+			queue.Workers++
+		}
+		if queue.Device.Rotational && f.HddWorkers > 0 {
+			queue.Workers = min(queue.Workers, f.HddWorkers)
+		}
+		queue.Workers = max(queue.Workers, 1)
+	}
+	return queues
+}
+
+func (app *App) buildLocationLimits() LocationLimits {
+	limits := make(LocationLimits)
+	for location, workers := range app.Flags.LocationWorkers {
+		if workers > 0 {
+			limits[filepath.Clean(location)] = make(chan struct{}, workers)
+		}
+	}
+	return limits
+}
+
+// Acquire blocks until the location of the file has capacity, returning the release func.
+func (ll LocationLimits) Acquire(file *indexer.FileFS) func() {
+	limit, ok := ll[filepath.Clean(file.Location)]
+	if !ok {
+		return func() {}
+	}
+	limit <- struct{}{}
+	return func() { <-limit }
+}
+
+// indexQueue walks every location of the queue, sending files in inode order
+// when the queue is ordered.
+func (app *App) indexQueue(ctx context.Context, queue *DeviceQueue) {
+	wk := app.Runtime.IndexerConfig
+	walkOptions := indexer.WalkConfig{Recurse: app.Flags.Recurse}
+
+	files := queue.Files
+	if queue.Ordered {
+		ordered := make(chan *indexer.FileFS)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sendInodeOrder(ctx, ordered, queue.Files)
+		}()
+		defer func() {
+			close(ordered)
+			<-done
+		}()
+		files = ordered
+	}
+
+	for _, location := range queue.Locations {
+		app.locationIndexing(location.Name)
+		app.Progress.setLocation(location.Name)
+		walkOptions.OnSkip = app.skipper(location.Name)
+		err := wk.WalkDirectory(ctx, location.FS, location.Name, walkOptions, files)
+		if ctx.Err() != nil {
+			return
+		}
+		app.recordLocationFail(location, err)
+	}
+}
+
+// sendInodeOrder Forwards files a batch at a time, each batch sorted by inode.
+func sendInodeOrder(ctx context.Context, in <-chan *indexer.FileFS, out chan<- *indexer.FileFS) {
+	batch := make([]*indexer.FileFS, 0, orderedBatch)
+	flush := func() {
+		slices.SortStableFunc(batch, func(a, b *indexer.FileFS) int {
+			return cmp.Compare(a.Meta.Inode, b.Meta.Inode)
+		})
+		for _, file := range batch {
+			select {
+			case out <- file:
+			case <-ctx.Done():
+			}
+		}
+		batch = batch[:0]
+	}
+	for file := range in {
+		if ctx.Err() != nil {
+			continue
+		}
+		if batch = append(batch, file); len(batch) == orderedBatch {
+			flush()
+		}
+	}
+	if ctx.Err() == nil {
+		flush()
+	}
+}
+
+func (app *App) recordLocationFail(location indexer.LocationFS, err error) {
+	if err == nil {
+		return
+	}
+	app.Logger.Info("skipped location", "path", location.Name, "location", location.Name, "kind", ClassifyFail(err), "error", err)
+	if _, loaded := app.Session.Fails.LoadAndStore(location.Name, err); !loaded {
+		app.Progress.Fails.Inc()
+		app.Metrics.observeFail()
+		app.fileFailed(location.Name, err)
+	}
+}
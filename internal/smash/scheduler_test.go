@@ -0,0 +1,184 @@
+package smash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+func TestBuildDeviceQueues(t *testing.T) {
+	ssd := indexer.Device{ID: 1, Known: true}
+	hdd := indexer.Device{ID: 2, Known: true, Rotational: true}
+	usb := indexer.Device{ID: 3, Known: true}
+
+	tests := []struct {
+		name            string
+		locations       []indexer.LocationFS
+		expectedWorkers []int
+		expectedOrdered []bool
+		hddWorkers      int
+	}{
+		{
+			name:            "Should share a queue for unknown devices",
+			locations:       []indexer.LocationFS{{Name: "a"}, {Name: "b"}},
+			expectedWorkers: []int{8},
+			expectedOrdered: []bool{false},
+		},
+		{
+			name:            "Should share a queue for locations on the same device",
+			locations:       []indexer.LocationFS{{Name: "a", Device: ssd}, {Name: "b", Device: ssd}},
+			expectedWorkers: []int{8},
+			expectedOrdered: []bool{false},
+		},
+		{
+			name:            "Should split the workers between devices & order rotational devices",
+			locations:       []indexer.LocationFS{{Name: "a", Device: ssd}, {Name: "b", Device: hdd}},
+			expectedWorkers: []int{4, 4},
+			expectedOrdered: []bool{false, true},
+		},
+		{
+			name:            "Should give the remainder to the first devices",
+			locations:       []indexer.LocationFS{{Name: "a", Device: ssd}, {Name: "b", Device: hdd}, {Name: "c", Device: usb}},
+			expectedWorkers: []int{3, 3, 2},
+			expectedOrdered: []bool{false, true, false},
+		},
+		{
+			name:            "Should limit rotational devices when hdd workers are set",
+			locations:       []indexer.LocationFS{{Name: "a", Device: ssd}, {Name: "b", Device: hdd}},
+			hddWorkers:      1,
+			expectedWorkers: []int{4, 1},
+			expectedOrdered: []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{
+				Flags:     &Flags{MaxWorkers: 8, HddWorkers: tt.hddWorkers},
+				Locations: tt.locations,
+			}
+
+			queues := app.buildDeviceQueues()
+
+			if len(queues) != len(tt.expectedWorkers) {
+				t.Fatalf("expected %d queues, got %d", len(tt.expectedWorkers), len(queues))
+			}
+			for i, queue := range queues {
+				if queue.Workers != tt.expectedWorkers[i] {
+					t.Errorf("queue %d expected %d workers, got %d", i, tt.expectedWorkers[i], queue.Workers)
+				}
+				if queue.Ordered != tt.expectedOrdered[i] {
+					t.Errorf("queue %d expected ordered %t, got %t", i, tt.expectedOrdered[i], queue.Ordered)
+				}
+			}
+		})
+	}
+}
+
+func TestLocationLimitsAcquire(t *testing.T) {
+	app := &App{Flags: &Flags{LocationWorkers: map[string]int{"/mnt/nas/": 1}}}
+	limits := app.buildLocationLimits()
+
+	nas := &indexer.FileFS{Location: "/mnt/nas"}
+	release := limits.Acquire(nas)
+
+	acquired := make(chan struct{})
+	go func() {
+		limits.Acquire(nas)()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected second acquire to block while the location is at capacity")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected second acquire to proceed after release")
+	}
+
+	// Unlimited locations never block
+	limits.Acquire(&indexer.FileFS{Location: "/mnt/ssd"})()
+}
+
+func TestIndexQueueOrdersByInode(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"c.bin", "a.bin", "b.bin", "d.bin"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	app := &App{
+		Flags:    &Flags{Recurse: true},
+		Session:  &AppSession{},
+		Runtime:  &AppRuntime{IndexerConfig: indexer.New()},
+		Progress: NewAppProgress(),
+	}
+	queue := &DeviceQueue{
+		Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, os.DirFS(tempDir))},
+		Files:     make(chan *indexer.FileFS),
+		Ordered:   true,
+	}
+
+	go func() {
+		defer close(queue.Files)
+		app.indexQueue(context.Background(), queue)
+	}()
+
+	var files []*indexer.FileFS
+	for file := range queue.Files {
+		files = append(files, file)
+	}
+
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files, got %d", len(files))
+	}
+	for i := 1; i < len(files); i++ {
+		if files[i-1].Meta.Inode > files[i].Meta.Inode {
+			t.Errorf("expected files in inode order, got %d before %d", files[i-1].Meta.Inode, files[i].Meta.Inode)
+		}
+	}
+}
+
+func TestSendInodeOrderSortsInBatches(t *testing.T) {
+	in := make(chan *indexer.FileFS)
+	out := make(chan *indexer.FileFS)
+	total := orderedBatch*2 + 1
+	go func() {
+		defer close(in)
+		for i := total; i > 0; i-- {
+			in <- &indexer.FileFS{Meta: indexer.FileMeta{Inode: uint64(i)}}
+		}
+	}()
+	go func() {
+		defer close(out)
+		sendInodeOrder(context.Background(), in, out)
+	}()
+
+	var files []*indexer.FileFS
+	for file := range out {
+		files = append(files, file)
+	}
+
+	if len(files) != total {
+		t.Fatalf("expected %d files, got %d", total, len(files))
+	}
+	for start := 0; start < total; start += orderedBatch {
+		batch := files[start:min(start+orderedBatch, total)]
+		for i := 1; i < len(batch); i++ {
+			if batch[i-1].Meta.Inode > batch[i].Meta.Inode {
+				t.Fatalf("expected batch at %d in inode order, got %d before %d", start, batch[i-1].Meta.Inode, batch[i].Meta.Inode)
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package smash
+
+import (
+	_ "embed"
+)
+
+// ReportSchema is the JSON Schema of the report, matching ReportSchemaVersion.
+//
+//go:embed schema/report.schema.json
+var ReportSchema []byte
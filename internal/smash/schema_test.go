@@ -0,0 +1,74 @@
+package smash
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type jsonSchema struct {
+	Properties map[string]jsonSchema `json:"properties"`
+	Defs       map[string]jsonSchema `json:"$defs"`
+	Const      any                   `json:"const"`
+}
+
+func TestReportSchemaMatchesReport(t *testing.T) {
+	var schema jsonSchema
+	if err := json.Unmarshal(ReportSchema, &schema); err != nil {
+		t.Fatalf("report schema is not valid JSON: %v", err)
+	}
+
+	if version := schema.Properties["_meta"].Properties["schemaVersion"].Const; version != float64(ReportSchemaVersion) {
+		t.Errorf("expected schema version %d, got %v", ReportSchemaVersion, version)
+	}
+
+	tests := []struct {
+		name   string
+		schema jsonSchema
+		report any
+	}{
+		{name: "_meta", schema: schema.Properties["_meta"], report: ReportMeta{}},
+		{name: "summary", schema: schema.Properties["summary"], report: ReportSummary{}},
+		{name: "analysis", schema: schema.Properties["analysis"], report: ReportFiles{}},
+		{name: "fail", schema: schema.Defs["fail"], report: ReportFailSummary{}},
+		{name: "skipped", schema: schema.Defs["skipped"], report: ReportSkipSummary{}},
+		{name: "overlap", schema: schema.Defs["overlap"], report: ReportOverlapSummary{}},
+		{name: "fileBase", schema: schema.Defs["fileBase"], report: ReportFileBaseSummary{}},
+		{name: "file", schema: schema.Defs["file"], report: ReportFileSummary{}},
+		{name: "known", schema: schema.Defs["known"], report: ReportKnownSummary{}},
+		{name: "group", schema: schema.Defs["group"], report: ReportDuplicateSummary{}},
+		{name: "top", schema: schema.Defs["top"], report: ReportTopFilesSummary{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := jsonFieldNames(reflect.TypeOf(tt.report))
+			var actual []string
+			for name := range tt.schema.Properties {
+				actual = append(actual, name)
+			}
+			sort.Strings(actual)
+
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("schema properties %v, report fields %v", actual, expected)
+			}
+		})
+	}
+}
+
+// jsonFieldNames returns the JSON names of the fields declared by the struct, excluding embedded structs
+func jsonFieldNames(rt reflect.Type) []string {
+	var names []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
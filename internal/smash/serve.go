@@ -0,0 +1,408 @@
+package smash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+// DefaultServeAddress only listens locally, anyone who can reach the API can
+// read any file smash can.
+const DefaultServeAddress = "localhost:1985"
+
+// DefaultServeRetain is how many finished scans smash serve keeps, older ones are forgotten.
+const DefaultServeRetain = 100
+
+type ScanState string
+
+const (
+	ScanRunning   ScanState = "running"
+	ScanFinished  ScanState = "finished"
+	ScanFailed    ScanState = "failed"
+	ScanCancelled ScanState = "cancelled"
+)
+
+// ScanRequest starts a scan, anything not given uses the flags smash serve was started with.
+type ScanRequest struct {
+	Recurse        *bool    `json:"recurse"`
+	DisableSlicing *bool    `json:"disableSlicing"`
+	IgnoreEmpty    *bool    `json:"ignoreEmpty"`
+	IgnoreHidden   *bool    `json:"ignoreHidden"`
+	IgnoreSystem   *bool    `json:"ignoreSystem"`
+	MinSize        *int64   `json:"minSize"`
+	MaxSize        *int64   `json:"maxSize"`
+	Locations      []string `json:"locations"`
+	Algorithms     []string `json:"algorithms"`
+	ExcludeDir     []string `json:"excludeDir"`
+	ExcludeFile    []string `json:"excludeFile"`
+}
+
+type ScanStatus struct {
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt,omitzero"`
+	Summary    *ReportSummary `json:"summary,omitempty"`
+	ID         string         `json:"id"`
+	State      ScanState      `json:"state"`
+	Location   string         `json:"location,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Locations  []string       `json:"locations"`
+	Files      int64          `json:"files"`
+	Fails      int64          `json:"fails"`
+}
+
+type Server struct {
+	// Logger is given to every scan, nothing is logged without one
+	Logger *slog.Logger
+	// ctx is cancelled when the server stops, every scan is derived from it
+	ctx      context.Context
+	stop     context.CancelFunc
+	flags    *Flags
+	metrics  *Metrics
+	scans    map[string]*serveScan
+	running  *serveScan
+	order    []string
+	scanning sync.WaitGroup
+	retain   int
+	scanned  int
+	mu       sync.RWMutex
+}
+
+type serveScan struct {
+	startedAt  time.Time
+	finishedAt time.Time
+	cancel     context.CancelFunc
+	progress   *AppProgress
+	report     *ReportOutput
+	err        error
+	id         string
+	locations  []string
+	cancelled  bool
+	mu         sync.RWMutex
+}
+
+// NewServer Creates the API of smash serve, scans default to the flags given & the
+// last --retain finished scans are kept.
+func NewServer(flags *Flags) *Server {
+	ctx, stop := context.WithCancel(context.Background())
+	retain := flags.ServeRetain
+	if retain < 1 {
+		retain = DefaultServeRetain
+	}
+	return &Server{
+		ctx:     ctx,
+		stop:    stop,
+		flags:   flags,
+		metrics: NewMetrics(),
+		scans:   make(map[string]*serveScan),
+		retain:  retain,
+	}
+}
+
+// Close Cancels every running scan & waits for them to stop, no more can be started.
+func (s *Server) Close() {
+	s.stop()
+	s.scanning.Wait()
+}
+
+// ListenAndServe Serves the API until the context is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      time.Minute,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+	err := server.ListenAndServe()
+	s.Close()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler Returns the routes of the API:
+//
+//	POST /scans              start a scan (ScanRequest)
+//	GET  /scans              status of every scan
+//	GET  /scans/{id}         status & progress of a scan
+//	GET  /scans/{id}/report  report of a finished (or cancelled) scan, see `smash schema`
+//	DELETE /scans/{id}       cancel a running scan, keeping what was smashed by then
+//	GET  /metrics            Prometheus metrics of every scan
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", s.metrics.Handler())
+	mux.HandleFunc("POST /scans", s.startScan)
+	mux.HandleFunc("GET /scans", s.listScans)
+	mux.HandleFunc("GET /scans/{id}", s.getScan)
+	mux.HandleFunc("GET /scans/{id}/report", s.getReport)
+	mux.HandleFunc("DELETE /scans/{id}", s.cancelScan)
+	return mux
+}
+
+func (s *Server) startScan(w http.ResponseWriter, r *http.Request) {
+	var request ScanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid scan request: %w", err))
+		return
+	}
+	app, err := s.newApp(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, errors.New("server is stopping"))
+		return
+	}
+	if s.running != nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("scan %s is still running", s.running.id))
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.scanned++
+	scan := &serveScan{
+		id:        strconv.Itoa(s.scanned),
+		locations: request.Locations,
+		progress:  app.Progress,
+		startedAt: time.Now(),
+		cancel:    cancel,
+	}
+	s.scans[scan.id] = scan
+	s.order = append(s.order, scan.id)
+	s.running = scan
+	s.scanning.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.scanning.Done()
+		report, cancelled, err := scan.run(ctx, app)
+		s.finishScan(scan, report, cancelled, err)
+	}()
+
+	w.Header().Set("Location", "/scans/"+scan.id)
+	writeJSON(w, http.StatusAccepted, scan.status())
+}
+
+// finishScan Records how the running scan finished so another can start, forgetting the
+// oldest finished scans beyond those retained.
+func (s *Server) finishScan(scan *serveScan, report *ReportOutput, cancelled bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scan.mu.Lock()
+	scan.err = err
+	scan.report = report
+	scan.cancelled = cancelled
+	scan.finishedAt = time.Now()
+	scan.mu.Unlock()
+	s.running = nil
+
+	if evicted := len(s.order) - s.retain; evicted > 0 {
+		for _, id := range s.order[:evicted] {
+			delete(s.scans, id)
+		}
+		s.order = slices.Delete(s.order, 0, evicted)
+	}
+}
+
+func (s *Server) listScans(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	statuses := make([]ScanStatus, len(s.order))
+	for i, id := range s.order {
+		statuses[i] = s.scans[id].status()
+	}
+	s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *Server) getScan(w http.ResponseWriter, r *http.Request) {
+	if scan, ok := s.scan(w, r); ok {
+		writeJSON(w, http.StatusOK, scan.status())
+	}
+}
+
+func (s *Server) getReport(w http.ResponseWriter, r *http.Request) {
+	scan, ok := s.scan(w, r)
+	if !ok {
+		return
+	}
+	scan.mu.RLock()
+	report, err := scan.report, scan.err
+	scan.mu.RUnlock()
+	switch {
+	case err != nil && report == nil:
+		writeError(w, http.StatusConflict, fmt.Errorf("scan %s failed: %w", scan.id, err))
+	case report == nil:
+		writeError(w, http.StatusConflict, fmt.Errorf("scan %s is still running", scan.id))
+	default:
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// cancelScan Stops a running scan, its report covers the files smashed until then.
+func (s *Server) cancelScan(w http.ResponseWriter, r *http.Request) {
+	scan, ok := s.scan(w, r)
+	if !ok {
+		return
+	}
+	if scan.status().State != ScanRunning {
+		writeError(w, http.StatusConflict, fmt.Errorf("scan %s isn't running", scan.id))
+		return
+	}
+	scan.cancel()
+	writeJSON(w, http.StatusAccepted, scan.status())
+}
+
+func (s *Server) scan(w http.ResponseWriter, r *http.Request) (*serveScan, bool) {
+	id := r.PathValue("id")
+	s.mu.RLock()
+	scan, ok := s.scans[id]
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no scan %q", id))
+	}
+	return scan, ok
+}
+
+// newApp Creates an app for the request, reports are only kept in memory &
+// nothing is written or prompted for, scans can't resume or checkpoint.
+func (s *Server) newApp(request ScanRequest) (*App, error) {
+	if len(request.Locations) == 0 {
+		return nil, errors.New("at least one location is required")
+	}
+	flags := *s.flags
+	flags.HideOutput = true
+	flags.Interactive = false
+	flags.OutputFile = ""
+	flags.ManifestFile = ""
+	flags.CheckpointFile = ""
+	flags.ResumeFile = ""
+	flags.MetricsAddress = ""
+	flags.MetricsPush = ""
+
+	if request.Recurse != nil {
+		flags.Recurse = *request.Recurse
+	}
+	if request.DisableSlicing != nil {
+		flags.DisableSlicing = *request.DisableSlicing
+	}
+	if request.IgnoreEmpty != nil {
+		flags.IgnoreEmpty = *request.IgnoreEmpty
+	}
+	if request.IgnoreHidden != nil {
+		flags.IgnoreHidden = *request.IgnoreHidden
+	}
+	if request.IgnoreSystem != nil {
+		flags.IgnoreSystem = *request.IgnoreSystem
+	}
+	if request.MinSize != nil {
+		flags.MinSize = *request.MinSize
+	}
+	if request.MaxSize != nil {
+		flags.MaxSize = *request.MaxSize
+	}
+	if request.ExcludeDir != nil {
+		flags.ExcludeDir = request.ExcludeDir
+	}
+	if request.ExcludeFile != nil {
+		flags.ExcludeFile = request.ExcludeFile
+	}
+	if len(request.Algorithms) > 0 {
+		flags.Algorithms = make([]int, len(request.Algorithms))
+		for i, name := range request.Algorithms {
+			algorithm, ok := algorithms.Parse(name)
+			if !ok {
+				return nil, fmt.Errorf("unsupported algorithm %q", name)
+			}
+			flags.Algorithms[i] = int(algorithm)
+		}
+	}
+
+	locations := make([]indexer.LocationFS, 0, len(request.Locations))
+	for _, location := range request.Locations {
+		if _, err := os.Stat(location); err != nil {
+			return nil, fmt.Errorf("invalid location %q: %w", location, err)
+		}
+		locations = append(locations, *indexer.NewLocationFS(indexer.Local, location, os.DirFS(location)))
+	}
+
+	return &App{
+		Flags:     &flags,
+		Args:      request.Locations,
+		Locations: locations,
+		Progress:  NewAppProgress(),
+		Metrics:   s.metrics,
+		Logger:    s.Logger,
+	}, nil
+}
+
+// run Smashes the scan's locations until done or cancelled, a cancelled scan
+// keeps the partial report of what was smashed by then.
+func (scan *serveScan) run(ctx context.Context, app *App) (*ReportOutput, bool, error) {
+	defer scan.cancel()
+	err := app.Run(ctx)
+	cancelled := ctx.Err() != nil && app.Summary != nil
+	if err != nil && !cancelled {
+		return nil, false, err
+	}
+	report := app.GenerateReportOutput()
+	return &report, cancelled, err
+}
+
+func (scan *serveScan) status() ScanStatus {
+	scan.mu.RLock()
+	defer scan.mu.RUnlock()
+	status := ScanStatus{
+		ID:         scan.id,
+		State:      ScanRunning,
+		Locations:  scan.locations,
+		Location:   scan.progress.Location(),
+		Files:      scan.progress.Files.Value(),
+		Fails:      scan.progress.Fails.Value(),
+		StartedAt:  scan.startedAt,
+		FinishedAt: scan.finishedAt,
+	}
+	switch {
+	case scan.cancelled:
+		status.State = ScanCancelled
+		status.Summary = &scan.report.Summary
+	case scan.err != nil:
+		status.State = ScanFailed
+		status.Error = scan.err.Error()
+	case scan.report != nil:
+		status.State = ScanFinished
+		status.Summary = &scan.report.Summary
+	}
+	return status
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,270 @@
+package smash
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+func TestServerScan(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"DSC19841.ARW", "DSC19842.ARW"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("smash"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(NewServer(&Flags{
+		Algorithms:     []int{int(algorithms.Xxhash)},
+		MaxWorkers:     2,
+		MaxThreads:     2,
+		Slices:         4,
+		SliceSize:      8192,
+		SliceThreshold: 102400,
+		ShowTop:        10,
+		ProgressUpdate: 5,
+	}).Handler())
+	defer server.Close()
+
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	resp, err := http.Post(server.URL+"/scans", "application/json", strings.NewReader(`{"locations":["`+filepath.ToSlash(tempDir)+`"],"algorithms":["sha256"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status ScanStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || status.ID != "1" {
+		t.Fatalf("expected scan 1 to be accepted, got %d %+v", resp.StatusCode, status)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for status.State == ScanRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		get("/scans/1", &status)
+	}
+	if status.State != ScanFinished || status.Files != 2 || status.Summary == nil || status.Summary.DuplicateFiles != 1 {
+		t.Fatalf("expected a finished scan of 2 files with 1 duplicate, got %+v", status)
+	}
+
+	var report ReportOutput
+	if code := get("/scans/1/report", &report); code != http.StatusOK {
+		t.Fatalf("expected the report, got %d", code)
+	}
+	if len(report.Analysis.Dupes) != 1 || report.Meta.Config.PrimaryAlgorithm() != algorithms.Sha256 {
+		t.Errorf("expected 1 group of duplicates hashed with sha256, got %+v", report.Analysis.Dupes)
+	}
+
+	if code := get("/scans/2", nil); code != http.StatusNotFound {
+		t.Errorf("expected %d for an unknown scan, got %d", http.StatusNotFound, code)
+	}
+}
+
+func TestServerCancelScan(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := range 2000 {
+		if err := os.WriteFile(filepath.Join(tempDir, fmt.Sprintf("DSC%05d.ARW", i)), []byte("smash"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	smash := NewServer(&Flags{
+		Algorithms:     []int{int(algorithms.Xxhash)},
+		MaxWorkers:     1,
+		Slices:         4,
+		SliceSize:      8192,
+		SliceThreshold: 102400,
+		ShowTop:        10,
+		ProgressUpdate: 5,
+	})
+	server := httptest.NewServer(smash.Handler())
+	defer server.Close()
+
+	do := func(method, path string, v any) int {
+		t.Helper()
+		request, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	resp, err := http.Post(server.URL+"/scans", "application/json", strings.NewReader(`{"locations":["`+filepath.ToSlash(tempDir)+`"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if code := do(http.MethodDelete, "/scans/1", nil); code != http.StatusAccepted {
+		t.Skipf("scan finished before it could be cancelled (%d)", code)
+	}
+	var status ScanStatus
+	do(http.MethodGet, "/scans/1", &status)
+	deadline := time.Now().Add(10 * time.Second)
+	for status.State == ScanRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		do(http.MethodGet, "/scans/1", &status)
+	}
+	if status.State != ScanCancelled || status.Summary == nil || !status.Summary.Partial {
+		t.Fatalf("expected a cancelled scan with a partial summary, got %+v", status)
+	}
+	var report ReportOutput
+	if code := do(http.MethodGet, "/scans/1/report", &report); code != http.StatusOK || !report.Summary.Partial {
+		t.Errorf("expected the partial report, got %d", code)
+	}
+	if code := do(http.MethodDelete, "/scans/1", nil); code != http.StatusConflict {
+		t.Errorf("expected %d cancelling a cancelled scan, got %d", http.StatusConflict, code)
+	}
+
+	smash.Close()
+	resp, err = http.Post(server.URL+"/scans", "application/json", strings.NewReader(`{"locations":["`+filepath.ToSlash(tempDir)+`"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected %d once the server is closed, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+func TestServerForgetsOldScans(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "DSC19841.ARW"), []byte("smash"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	smash := NewServer(&Flags{
+		Algorithms:     []int{int(algorithms.Xxhash)},
+		MaxWorkers:     1,
+		Slices:         4,
+		SliceSize:      8192,
+		SliceThreshold: 102400,
+		ShowTop:        10,
+		ProgressUpdate: 5,
+		ServeRetain:    2,
+	})
+	server := httptest.NewServer(smash.Handler())
+	defer server.Close()
+
+	for range 3 {
+		resp, err := http.Post(server.URL+"/scans", "application/json", strings.NewReader(`{"locations":["`+filepath.ToSlash(tempDir)+`"]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected the scan to be accepted, got %d", resp.StatusCode)
+		}
+		smash.scanning.Wait()
+	}
+
+	resp, err := http.Get(server.URL + "/scans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []ScanStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(statuses) != 2 || statuses[0].ID != "2" || statuses[1].ID != "3" {
+		t.Errorf("expected only scans 2 & 3 to be kept, got %+v", statuses)
+	}
+
+	for path, expected := range map[string]int{
+		"/scans/1":        http.StatusNotFound,
+		"/scans/1/report": http.StatusNotFound,
+		"/scans/3/report": http.StatusOK,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("expected %d for %s, got %d", expected, path, resp.StatusCode)
+		}
+	}
+}
+
+func TestServerNewAppDoesNotPersist(t *testing.T) {
+	server := NewServer(&Flags{
+		OutputFile:     "report.json",
+		ManifestFile:   "SHA256SUMS",
+		CheckpointFile: "smash.checkpoint",
+		ResumeFile:     "smash.checkpoint",
+		MetricsAddress: "localhost:9090",
+		MetricsPush:    "http://localhost:9091",
+		Interactive:    true,
+	})
+	app, err := server.newApp(ScanRequest{Locations: []string{t.TempDir()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := app.Flags
+	if f.OutputFile != "" || f.ManifestFile != "" || f.CheckpointFile != "" || f.ResumeFile != "" || f.MetricsAddress != "" || f.MetricsPush != "" || f.Interactive || !f.HideOutput {
+		t.Errorf("expected scans to only keep their report in memory, got %+v", f)
+	}
+}
+
+func TestServerInvalidScanRequest(t *testing.T) {
+	server := httptest.NewServer(NewServer(&Flags{}).Handler())
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not json", body: "smash"},
+		{name: "no locations", body: `{}`},
+		{name: "missing location", body: `{"locations":["/does/not/exist"]}`},
+		{name: "unsupported algorithm", body: `{"locations":["."],"algorithms":["tiger"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/scans", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+		})
+	}
+}
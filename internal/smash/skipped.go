@@ -0,0 +1,83 @@
+package smash
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+// SkippedFiles Counts the files & directories that weren't smashed by reason,
+// listing them as well with --report-skipped.
+type SkippedFiles struct {
+	Counts map[indexer.SkipReason]int64
+	Files  []SkippedFile
+	list   bool
+	sync.Mutex
+}
+type SkippedFile struct {
+	Path   string
+	Reason indexer.SkipReason
+	Dir    bool
+}
+
+func newSkippedFiles(list bool) *SkippedFiles {
+	return &SkippedFiles{Counts: make(map[indexer.SkipReason]int64), list: list}
+}
+
+func (s *SkippedFiles) add(path string, dir bool, reason indexer.SkipReason) {
+	s.Lock()
+	defer s.Unlock()
+	s.Counts[reason]++
+	if s.list {
+		s.Files = append(s.Files, SkippedFile{Path: path, Reason: reason, Dir: dir})
+	}
+}
+
+// counts Returns a copy of the counts, safe to keep once smashing finishes.
+func (s *SkippedFiles) counts() map[indexer.SkipReason]int64 {
+	s.Lock()
+	defer s.Unlock()
+	counts := make(map[indexer.SkipReason]int64, len(s.Counts))
+	for reason, count := range s.Counts {
+		counts[reason] = count
+	}
+	return counts
+}
+
+// skipper Returns the WalkConfig.OnSkip for a location, recording what the indexer skipped.
+func (app *App) skipper(location string) func(string, bool, indexer.SkipReason, error) {
+	return func(path string, dir bool, reason indexer.SkipReason, err error) {
+		app.recordSkip(filepath.Join(location, path), location, dir, reason, err)
+	}
+}
+
+func (app *App) recordSkip(fullName string, location string, dir bool, reason indexer.SkipReason, err error) {
+	app.Session.Skipped.add(fullName, dir, reason)
+	if err != nil {
+		app.Logger.Info("skipped", "path", fullName, "location", location, "dir", dir, "kind", reason, "error", err)
+		return
+	}
+	app.Logger.Debug("skipped", "path", fullName, "location", location, "dir", dir, "kind", reason)
+}
+
+func summariseSkippedFiles(skipped *SkippedFiles) []ReportSkipSummary {
+	skipped.Lock()
+	defer skipped.Unlock()
+	if len(skipped.Files) == 0 {
+		return nil
+	}
+	summary := make([]ReportSkipSummary, len(skipped.Files))
+	for i, file := range skipped.Files {
+		summary[i] = ReportSkipSummary{
+			Path:   absolutePath(file.Path),
+			Reason: file.Reason,
+			Dir:    file.Dir,
+		}
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Path < summary[j].Path
+	})
+	return summary
+}
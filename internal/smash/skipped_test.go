@@ -0,0 +1,75 @@
+package smash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+func TestAppRecordsSkipped(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{
+		"DSC19841.ARW":      "smash",
+		"DSC19842.ARW":      "smash hits, too big to smash",
+		".DSC19843.ARW":     "smash",
+		".git/DSC19844.ARW": "smash",
+	} {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := &App{
+		Flags: &Flags{
+			Algorithms:     []int{int(algorithms.Xxhash)},
+			MaxWorkers:     1,
+			MaxThreads:     1,
+			MaxSize:        10,
+			SliceSize:      8192,
+			SliceThreshold: 102400,
+			Slices:         4,
+			ShowTop:        10,
+			ProgressUpdate: 5,
+			Recurse:        true,
+			IgnoreHidden:   true,
+			ReportSkipped:  true,
+			Silent:         true,
+			HideOutput:     true,
+		},
+		Locations: []indexer.LocationFS{*indexer.NewLocationFS(indexer.Local, tempDir, os.DirFS(tempDir))},
+	}
+	if err := app.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[indexer.SkipReason]int64{indexer.SkipHidden: 2, indexer.SkipSize: 1}
+	if !reflect.DeepEqual(app.Summary.Skipped, expected) {
+		t.Errorf("expected %v, got %v", expected, app.Summary.Skipped)
+	}
+
+	report := app.GenerateReportOutput()
+	if !reflect.DeepEqual(report.Summary.Skipped, expected) {
+		t.Errorf("expected %v in the report summary, got %v", expected, report.Summary.Skipped)
+	}
+	skipped := report.Analysis.Skipped
+	if len(skipped) != 3 {
+		t.Fatalf("expected 3 skipped files in the report, got %+v", skipped)
+	}
+	if skipped[0].Path != filepath.Join(tempDir, ".DSC19843.ARW") || skipped[0].Reason != indexer.SkipHidden || skipped[0].Dir {
+		t.Errorf("expected the hidden .DSC19843.ARW first, got %+v", skipped[0])
+	}
+	if skipped[1].Path != filepath.Join(tempDir, ".git") || !skipped[1].Dir {
+		t.Errorf("expected the hidden .git directory, got %+v", skipped[1])
+	}
+	if skipped[2].Reason != indexer.SkipSize {
+		t.Errorf("expected DSC19842.ARW to be skipped by size, got %+v", skipped[2])
+	}
+}
@@ -13,14 +13,20 @@
 )
 
 type File struct {
+	Digests     map[string]string
 	Filename    string
 	Location    string
 	Path        string
 	Base        string
 	Hash        string
 	FileSizeF   string
+	Strategy    string
+	ContentType string
+	Meta        indexer.FileMeta
 	FileSize    uint64
+	SliceSize   uint64
 	ElapsedTime int64
+	Slices      int
 	FullHash    bool
 	EmptyFile   bool
 }
@@ -33,18 +39,36 @@ type EmptyFiles struct {
 	sync.RWMutex
 }
 
-func SummariseSmashedFile(stats slicer.SlicerStats, ffs *indexer.FileFS, ms int64, duplicates *xsync.Map[string, *DuplicateFiles], empty *EmptyFiles) {
+// SummariseSmashedFile Adds the file to the duplicates (or empty files) & returns it.
+func SummariseSmashedFile(stats slicer.SlicerStats, ffs *indexer.FileFS, ms int64, duplicates *xsync.Map[string, *DuplicateFiles], empty *EmptyFiles) File {
 	file := File{
 		Hash:        hex.EncodeToString(stats.Hash),
 		Filename:    ffs.Name,
 		Location:    ffs.Location,
 		Path:        ffs.Path,
+		Meta:        ffs.Meta,
 		FileSize:    stats.FileSize,
 		FullHash:    stats.HashedFullFile,
+		Strategy:    stats.Strategy,
+		ContentType: stats.MIME,
+		Slices:      stats.Slices,
+		SliceSize:   stats.SliceSize,
 		EmptyFile:   stats.EmptyFile,
 		FileSizeF:   humanize.Bytes(stats.FileSize),
 		ElapsedTime: ms,
 	}
+	// sliced digests only cover the slices, they'd be mistaken for checksums of the whole file
+	if stats.HashedFullFile && len(stats.Digests) > 0 {
+		file.Digests = make(map[string]string, len(stats.Digests))
+		for algorithm, digest := range stats.Digests {
+			file.Digests[algorithm] = hex.EncodeToString(digest)
+		}
+	}
+	addSmashedFile(file, duplicates, empty)
+	return file
+}
+
+func addSmashedFile(file File, duplicates *xsync.Map[string, *DuplicateFiles], empty *EmptyFiles) {
 	if file.EmptyFile {
 		empty.Lock()
 		empty.Files = append(empty.Files, file)
@@ -58,5 +82,4 @@ func SummariseSmashedFile(stats slicer.SlicerStats, ffs *indexer.FileFS, ms int6
 		dupes.Files = append(dupes.Files, file)
 		dupes.Unlock()
 	}
-
 }
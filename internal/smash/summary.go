@@ -1,64 +1,26 @@
 package smash
 
 import (
-	"fmt"
-	"path/filepath"
-	"time"
-
 	"github.com/thushan/smash/pkg/analysis"
-
-	"github.com/thushan/smash/internal/theme"
+	"github.com/thushan/smash/pkg/indexer"
 )
 
 type RunSummary struct {
+	Skipped            map[indexer.SkipReason]int64
+	FailCodes          map[FailCode]int64
 	DuplicateFileSizeF string
 	ReportFilename     string
 	TopFiles           []analysis.Item
+	Overlaps           []DirectoryOverlap
+	Manifest           ManifestSummary
 	DuplicateFileSize  uint64
 	TotalFiles         int64
 	TotalFileErrors    int64
 	ElapsedTime        int64
 	UniqueFiles        int64
 	EmptyFiles         int64
+	KnownFiles         int64
+	KnownSliced        int64
 	DuplicateFiles     int64
-}
-
-func PrintRunSummary(rs RunSummary, flags *Flags) {
-	theme.StyleHeading.Println("---| Analysis Summary")
-
-	theme.Println(writeCategory("Total Time:"), theme.ColourTime(calcTotalTime(rs.ElapsedTime)))
-	theme.Println(writeCategory("Total Analysed:"), theme.ColourNumber(rs.TotalFiles))
-	theme.Println(writeCategory("Total Unique:"), theme.ColourNumber(rs.UniqueFiles), "(excludes empty files)")
-	if rs.TotalFileErrors > 0 {
-		theme.Println(writeCategory("Total Skipped:"), theme.ColourError(rs.TotalFileErrors))
-	}
-	theme.Println(writeCategory("Total Duplicates:"), theme.ColourNumber(rs.DuplicateFiles))
-	if !flags.IgnoreEmpty && rs.EmptyFiles > 0 {
-		theme.Println(writeCategory("Total Empty Files:"), theme.ColourNumber(rs.EmptyFiles))
-	}
-	if rs.DuplicateFileSize > 0 {
-		theme.Println(writeCategory("Space Reclaimable:"), theme.ColourFileSizeA(rs.DuplicateFileSizeF), "(approx)")
-	}
-	if !flags.HideOutput && rs.ReportFilename != "" {
-		filename := filepath.Clean(rs.ReportFilename)
-		reportUri := theme.Hyperlink("file://"+filename, filename)
-		theme.Println(writeCategory("Analysis Report:"), theme.StyleUrl(reportUri), "(json)")
-	}
-}
-func calcTotalTime(elapsedNs int64) string {
-	duration := time.Duration(elapsedNs)
-	switch {
-	case duration >= 60*time.Minute:
-		return duration.Round(time.Minute).String()
-	case duration >= 1*time.Minute:
-		return duration.Round(time.Second).String()
-	case duration <= 1*time.Second:
-		return duration.Round(time.Millisecond).String()
-	default:
-		return duration.Round(time.Second).String()
-	}
-}
-
-func writeCategory(category string) string {
-	return fmt.Sprintf("%20s", category)
+	Partial            bool
 }
@@ -0,0 +1,214 @@
+package smash
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/manifest"
+	"golang.org/x/sync/errgroup"
+)
+
+type VerifyStatus int
+
+const (
+	VerifyOK VerifyStatus = iota
+	VerifyChanged
+	VerifyMissing
+	VerifyExtra
+	VerifyFailed
+)
+
+type VerifyResult struct {
+	Err    error
+	Path   string
+	Status VerifyStatus
+}
+
+type VerifySummary struct {
+	OK      int
+	Changed int
+	Missing int
+	Extra   int
+	Failed  int
+}
+
+func (s VerifyStatus) String() string {
+	switch s {
+	case VerifyChanged:
+		return "CHANGED"
+	case VerifyMissing:
+		return "MISSING"
+	case VerifyExtra:
+		return "EXTRA"
+	case VerifyFailed:
+		return "FAILED"
+	}
+	return "OK"
+}
+
+// VerifyManifest Checks every file in the manifest, relative paths are resolved
+// from the working directory like sha256sum -c. Extra files are those found in
+// locations that aren't in the manifest (or the manifest itself).
+func VerifyManifest(m *manifest.Manifest, manifestPath string, locations []indexer.LocationFS, workers int) ([]VerifyResult, error) {
+	results := make([]VerifyResult, len(m.Entries))
+
+	var g errgroup.Group
+	g.SetLimit(max(workers, 1))
+	for i, entry := range m.Entries {
+		g.Go(func() error {
+			results[i] = verifyEntry(entry)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	extras, err := findExtras(m, manifestPath, locations)
+	if err != nil {
+		return results, err
+	}
+	return append(results, extras...), nil
+}
+
+func verifyEntry(entry manifest.Entry) VerifyResult {
+	result := VerifyResult{Path: entry.Path}
+	if entry.Path == "" {
+		result.Status = VerifyFailed
+		result.Err = errors.New("no path to verify")
+		return result
+	}
+	if len(entry.Digests) == 0 {
+		result.Status = VerifyFailed
+		result.Err = errors.New("no supported checksums")
+		return result
+	}
+
+	f, err := os.Open(entry.Path)
+	if err != nil {
+		result.Status = VerifyFailed
+		if errors.Is(err, os.ErrNotExist) {
+			result.Status = VerifyMissing
+			err = nil
+		}
+		result.Err = err
+		return result
+	}
+	defer f.Close()
+
+	if entry.Size != manifest.UnknownSize {
+		if fi, err := f.Stat(); err == nil && fi.Size() != entry.Size {
+			result.Status = VerifyChanged
+			return result
+		}
+	}
+
+	hashes := make(map[algorithms.Algorithm]hash.Hash, len(entry.Digests))
+	writers := make([]io.Writer, 0, len(entry.Digests))
+	for algorithm := range entry.Digests {
+		h, err := algorithm.New()
+		if err != nil {
+			result.Status = VerifyFailed
+			result.Err = err
+			return result
+		}
+		hashes[algorithm] = h
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		result.Status = VerifyFailed
+		result.Err = err
+		return result
+	}
+
+	for algorithm, digest := range entry.Digests {
+		if hex.EncodeToString(hashes[algorithm].Sum(nil)) != digest {
+			result.Status = VerifyChanged
+			return result
+		}
+	}
+	return result
+}
+
+func findExtras(m *manifest.Manifest, manifestPath string, locations []indexer.LocationFS) ([]VerifyResult, error) {
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]struct{}, len(m.Entries)+1)
+	known[absolutePath(manifestPath)] = struct{}{}
+	for _, entry := range m.Entries {
+		known[absolutePath(entry.Path)] = struct{}{}
+	}
+
+	// extras are about what's on disk, so nothing is hidden or ignored
+	wk := indexer.NewConfigured(nil, nil, false, false)
+	var extras []VerifyResult
+	for _, location := range locations {
+		files := make(chan *indexer.FileFS)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for file := range files {
+				if _, ok := known[absolutePath(file.FullName)]; ok {
+					continue
+				}
+				extras = append(extras, VerifyResult{Path: file.FullName, Status: VerifyExtra})
+			}
+		}()
+		err := wk.WalkDirectory(context.Background(), location.FS, location.Name, indexer.WalkConfig{Recurse: true}, files)
+		close(files)
+		<-done
+		if err != nil {
+			return extras, fmt.Errorf("failed to walk %s: %w", location.Name, err)
+		}
+	}
+	sort.Slice(extras, func(i, j int) bool {
+		return extras[i].Path < extras[j].Path
+	})
+	return extras, nil
+}
+
+// SummariseVerify Counts the results by their status.
+func SummariseVerify(results []VerifyResult) VerifySummary {
+	var summary VerifySummary
+	for _, result := range results {
+		switch result.Status {
+		case VerifyOK:
+			summary.OK++
+		case VerifyChanged:
+			summary.Changed++
+		case VerifyMissing:
+			summary.Missing++
+		case VerifyExtra:
+			summary.Extra++
+		case VerifyFailed:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// Err Returns an error when any file in the manifest didn't verify, extra files alone don't fail.
+func (s VerifySummary) Err() error {
+	var problems []string
+	if s.Changed > 0 {
+		problems = append(problems, fmt.Sprintf("%d changed", s.Changed))
+	}
+	if s.Missing > 0 {
+		problems = append(problems, fmt.Sprintf("%d missing", s.Missing))
+	}
+	if s.Failed > 0 {
+		problems = append(problems, fmt.Sprintf("%d failed", s.Failed))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("verification failed: " + strings.Join(problems, ", "))
+}
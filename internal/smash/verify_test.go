@@ -0,0 +1,81 @@
+package smash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/manifest"
+)
+
+func TestVerifyManifest(t *testing.T) {
+	const (
+		sha256Test  = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		sha256Empty = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	entry := func(path string, size int64, digest string) manifest.Entry {
+		return manifest.Entry{Path: path, Size: size, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: digest}}
+	}
+
+	m := &manifest.Manifest{Entries: []manifest.Entry{
+		entry(write("ok", "test"), 4, sha256Test),
+		entry(write("changed", "tset"), manifest.UnknownSize, sha256Test),
+		entry(write("resized", "tests"), 4, sha256Test),
+		entry(filepath.Join(dir, "missing"), 4, sha256Test),
+		{Path: write("unsupported", ""), Size: 0},
+		entry(write("empty", ""), 0, sha256Empty),
+	}}
+	manifestPath := write("sums.txt", "")
+	write("extra", "test")
+
+	results, err := VerifyManifest(m, manifestPath, []indexer.LocationFS{{FS: os.DirFS(dir), Name: dir}}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := map[string]VerifyStatus{
+		"ok":          VerifyOK,
+		"changed":     VerifyChanged,
+		"resized":     VerifyChanged,
+		"missing":     VerifyMissing,
+		"unsupported": VerifyFailed,
+		"empty":       VerifyOK,
+		"extra":       VerifyExtra,
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %+v", len(expected), results)
+	}
+	for _, result := range results {
+		name := filepath.Base(result.Path)
+		if result.Status != expected[name] {
+			t.Errorf("expected %s to be %v, got %v", name, expected[name], result.Status)
+		}
+	}
+
+	summary := SummariseVerify(results)
+	if summary.OK != 2 || summary.Changed != 2 || summary.Missing != 1 || summary.Failed != 1 || summary.Extra != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if summary.Err() == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestVerifySummaryErr(t *testing.T) {
+	if err := (VerifySummary{OK: 3, Extra: 2}).Err(); err != nil {
+		t.Errorf("expected extra files alone to pass, got %v", err)
+	}
+	if err := (VerifySummary{OK: 3, Missing: 1}).Err(); err == nil {
+		t.Error("expected missing files to fail")
+	}
+}
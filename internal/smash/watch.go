@@ -0,0 +1,401 @@
+package smash
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+// DefaultWatchSettle is how long a file must go unmodified before it's rehashed,
+// uploads write in chunks & would otherwise be hashed half-written.
+const DefaultWatchSettle = 2 * time.Second
+
+type WatchEventKind string
+
+const (
+	// WatchReady is emitted once the initial scan is done & locations are being watched
+	WatchReady WatchEventKind = "ready"
+	// WatchDuplicate is emitted when a created or modified file duplicates another
+	WatchDuplicate WatchEventKind = "duplicate"
+)
+
+type WatchEvent struct {
+	Time           time.Time      `json:"time"`
+	Kind           WatchEventKind `json:"event"`
+	Path           string         `json:"path,omitempty"`
+	Hash           string         `json:"hash,omitempty"`
+	Duplicates     []string       `json:"duplicates,omitempty"`
+	Size           uint64         `json:"size,omitempty"`
+	Files          int64          `json:"files,omitempty"`
+	DuplicateFiles int64          `json:"duplicateFiles,omitempty"`
+}
+
+type watcher struct {
+	app        *App
+	fsw        *fsnotify.Watcher
+	events     chan<- WatchEvent
+	hashes     *xsync.Map[string, string]
+	pending    map[string]*time.Timer
+	totalFiles *xsync.Counter
+	workers    chan struct{}
+	inflight   sync.WaitGroup
+	mu         sync.Mutex
+}
+
+// Watch Performs the initial scan (after Initialise) then keeps AppSession.Dupes up to date as files
+// change, emitting an event whenever a file becomes a duplicate. Unique files
+// are kept in the session so later arrivals can be matched against them.
+func (app *App) Watch(ctx context.Context, events chan<- WatchEvent) (int64, error) {
+	stopMetrics, err := app.startMetrics()
+	if err != nil {
+		return 0, err
+	}
+	defer stopMetrics()
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return 0, err
+	}
+	defer fsw.Close()
+
+	w := &watcher{
+		app:        app,
+		fsw:        fsw,
+		events:     events,
+		hashes:     xsync.NewMap[string, string](),
+		pending:    make(map[string]*time.Timer),
+		totalFiles: xsync.NewCounter(),
+		workers:    make(chan struct{}, max(app.Flags.MaxWorkers, 1)),
+	}
+
+	// watch before the initial scan so nothing written during it is missed
+	for i := range app.Locations {
+		if err := w.addDirs(&app.Locations[i], "."); err != nil {
+			return 0, err
+		}
+	}
+
+	indexed := app.startIndexing(ctx)
+	w.totalFiles.Add(app.processFiles(ctx))
+	<-indexed
+	if ctx.Err() != nil {
+		// stopped before the initial scan finished, there's nothing to watch
+		app.Session.EndTime = time.Now().UnixNano()
+		return w.totalFiles.Value(), nil
+	}
+
+	duplicates := int64(0)
+	app.Session.Dupes.Range(func(hash string, dupes *DuplicateFiles) bool {
+		for _, file := range dupes.Files {
+			w.hashes.Store(filepath.Join(file.Location, file.Path), hash)
+		}
+		duplicates += int64(len(dupes.Files) - 1)
+		return true
+	})
+	app.Metrics.observeDupes(app.Session.Dupes)
+	w.emit(ctx, WatchEvent{Kind: WatchReady, Files: w.totalFiles.Value(), DuplicateFiles: duplicates})
+
+	err = w.run(ctx)
+
+	w.mu.Lock()
+	for fullName := range w.pending {
+		w.cancel(fullName)
+	}
+	w.mu.Unlock()
+	w.inflight.Wait()
+	app.Session.EndTime = time.Now().UnixNano()
+
+	return w.totalFiles.Value(), err
+}
+
+func (w *watcher) run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.app.Logger.Warn("too many changes to keep up with, some files may not have been rehashed")
+				continue
+			}
+			return err
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(ctx, event)
+		}
+	}
+}
+
+func (w *watcher) handle(ctx context.Context, event fsnotify.Event) {
+	location, path, ok := w.locate(event.Name)
+	if !ok {
+		return
+	}
+	wk := w.app.Runtime.IndexerConfig
+
+	switch {
+	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+		w.mu.Lock()
+		w.cancel(event.Name)
+		w.forgetAll(event.Name)
+		w.mu.Unlock()
+		w.app.Metrics.observeDupes(w.app.Session.Dupes)
+	case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+		fi, err := os.Lstat(event.Name)
+		if err != nil {
+			return
+		}
+		if fi.IsDir() {
+			if !event.Has(fsnotify.Create) || !w.app.Flags.Recurse || wk.ExcludesDir(path, fi.Name()) {
+				return
+			}
+			if err := w.addDirs(location, path); err != nil {
+				w.app.Logger.Info("failed to watch", "path", event.Name, "location", location.Name, "kind", ClassifyFail(err), "error", err)
+			}
+			w.indexDir(ctx, location, path)
+			return
+		}
+		if fi.Mode().IsRegular() && !wk.ExcludesFile(fi.Name()) {
+			w.schedule(ctx, location, path)
+		}
+	}
+}
+
+// locate Returns the location a watched path is in & its path within the location.
+func (w *watcher) locate(name string) (*indexer.LocationFS, string, bool) {
+	for i := range w.app.Locations {
+		location := &w.app.Locations[i]
+		rel, err := filepath.Rel(location.Name, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return location, filepath.ToSlash(rel), true
+	}
+	return nil, "", false
+}
+
+// addDirs Watches the directory & (with --recurse) every directory below it that isn't excluded.
+func (w *watcher) addDirs(location *indexer.LocationFS, dir string) error {
+	wk := w.app.Runtime.IndexerConfig
+	return fs.WalkDir(location.FS, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) || errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && (!w.app.Flags.Recurse || wk.ExcludesDir(path, d.Name())) {
+			return fs.SkipDir
+		}
+		return w.fsw.Add(filepath.Join(location.Name, path))
+	})
+}
+
+// indexDir Schedules every file in a new directory, they may have been moved in
+// or written before the directory was watched.
+func (w *watcher) indexDir(ctx context.Context, location *indexer.LocationFS, dir string) {
+	files := make(chan *indexer.FileFS)
+	go func() {
+		defer close(files)
+		walkOptions := indexer.WalkConfig{Dir: dir, Recurse: w.app.Flags.Recurse}
+		_ = w.app.Runtime.IndexerConfig.WalkDirectory(ctx, location.FS, location.Name, walkOptions, files)
+	}()
+	for file := range files {
+		w.schedule(ctx, location, file.Path)
+	}
+}
+
+// schedule Rehashes the file once it has settled, every write pushes that back.
+func (w *watcher) schedule(ctx context.Context, location *indexer.LocationFS, path string) {
+	fullName := filepath.Join(location.Name, path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.pending[fullName]; ok && timer.Stop() {
+		timer.Reset(w.app.Flags.WatchSettle)
+		return
+	}
+	w.inflight.Add(1)
+	var timer *time.Timer
+	timer = time.AfterFunc(w.app.Flags.WatchSettle, func() {
+		defer w.inflight.Done()
+		w.mu.Lock()
+		if w.pending[fullName] == timer {
+			delete(w.pending, fullName)
+		}
+		w.mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		w.workers <- struct{}{}
+		w.rehash(ctx, location, path, fullName)
+		<-w.workers
+	})
+	w.pending[fullName] = timer
+}
+
+// cancel Stops a pending rehash, the caller must hold w.mu.
+func (w *watcher) cancel(fullName string) {
+	if timer, ok := w.pending[fullName]; ok {
+		if timer.Stop() {
+			w.inflight.Done()
+		}
+		delete(w.pending, fullName)
+	}
+}
+
+func (w *watcher) rehash(ctx context.Context, location *indexer.LocationFS, path string, fullName string) {
+	app := w.app
+	session := app.Session
+
+	fi, err := fs.Stat(location.FS, path)
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() == 0 {
+		// empty files are never duplicates worth reporting as they arrive
+		w.mu.Lock()
+		w.forget(fullName)
+		w.mu.Unlock()
+		return
+	}
+
+	file := &indexer.FileFS{
+		FileSystem: &location.FS,
+		Path:       path,
+		Name:       fi.Name(),
+		Location:   location.Name,
+		FullName:   fullName,
+		Meta:       indexer.ReadMeta(fi),
+	}
+	startTime := time.Now().UnixMilli()
+	stats, err := app.Runtime.Slicer.SliceFS(ctx, location.FS, path, app.Runtime.SlicerOptions)
+	elapsedMs := time.Now().UnixMilli() - startTime
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	w.mu.Lock()
+	event, duplicate := w.record(file, stats, elapsedMs, err)
+	w.mu.Unlock()
+
+	app.Metrics.observeDupes(session.Dupes)
+	if duplicate {
+		w.emit(ctx, event)
+	}
+}
+
+// record Replaces a rehashed file in the session, returning the event to emit if it's now a
+// duplicate. The caller must hold w.mu & emit the event once it's released.
+func (w *watcher) record(file *indexer.FileFS, stats slicer.SlicerStats, elapsedMs int64, err error) (WatchEvent, bool) {
+	app := w.app
+	session := app.Session
+	fullName := file.FullName
+
+	previous, _ := w.hashes.Load(fullName)
+	w.forget(fullName)
+	switch {
+	case err != nil:
+		app.Logger.Info("skipped file", "path", fullName, "location", file.Location, "kind", ClassifyFail(err), "error", err)
+		session.Fails.Store(fullName, err)
+		app.Metrics.observeFail()
+		app.fileFailed(fullName, err)
+		return WatchEvent{}, false
+	case stats.IgnoredFile:
+		return WatchEvent{}, false
+	}
+	session.Fails.Delete(fullName)
+
+	app.fileSmashed(SummariseSmashedFile(stats, file, elapsedMs, session.Dupes, session.Empty))
+	app.Metrics.observeHashed(stats, elapsedMs)
+	hash := hex.EncodeToString(stats.Hash)
+	w.hashes.Store(fullName, hash)
+	w.totalFiles.Inc()
+
+	dupes, ok := session.Dupes.Load(hash)
+	if !ok || hash == previous {
+		return WatchEvent{}, false
+	}
+	dupes.RLock()
+	var others []string
+	for _, f := range dupes.Files {
+		if other := filepath.Join(f.Location, f.Path); other != fullName {
+			others = append(others, absolutePath(other))
+		}
+	}
+	dupes.RUnlock()
+	if len(others) == 0 {
+		return WatchEvent{}, false
+	}
+	return WatchEvent{
+		Kind:       WatchDuplicate,
+		Path:       absolutePath(fullName),
+		Hash:       hash,
+		Size:       stats.FileSize,
+		Duplicates: others,
+	}, true
+}
+
+// forget Removes a file from the session, the caller must hold w.mu.
+func (w *watcher) forget(fullName string) {
+	hash, ok := w.hashes.LoadAndDelete(fullName)
+	if !ok {
+		return
+	}
+	dupes, ok := w.app.Session.Dupes.Load(hash)
+	if !ok {
+		return
+	}
+	dupes.Lock()
+	files := dupes.Files[:0]
+	for _, file := range dupes.Files {
+		if filepath.Join(file.Location, file.Path) != fullName {
+			files = append(files, file)
+		}
+	}
+	dupes.Files = files
+	dupes.Unlock()
+	if len(files) == 0 {
+		w.app.Session.Dupes.Delete(hash)
+	}
+}
+
+// forgetAll Removes a file, or every file below a directory, from the session. The caller must hold w.mu.
+func (w *watcher) forgetAll(name string) {
+	w.forget(name)
+	prefix := name + string(filepath.Separator)
+	var below []string
+	w.hashes.Range(func(fullName string, _ string) bool {
+		if strings.HasPrefix(fullName, prefix) {
+			below = append(below, fullName)
+		}
+		return true
+	})
+	for _, fullName := range below {
+		w.forget(fullName)
+	}
+}
+
+func (w *watcher) emit(ctx context.Context, event WatchEvent) {
+	event.Time = time.Now()
+	select {
+	case w.events <- event:
+	case <-ctx.Done():
+	}
+}
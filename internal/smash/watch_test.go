@@ -0,0 +1,95 @@
+package smash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+func TestWatchReportsNewDuplicates(t *testing.T) {
+	tempDir := t.TempDir()
+	write := func(name string, content string) {
+		t.Helper()
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("uploads/DSC19841.ARW", "smash")
+
+	app := &App{
+		Flags: &Flags{
+			Algorithms:     []int{int(algorithms.Xxhash)},
+			MaxWorkers:     2,
+			MaxThreads:     2,
+			Slices:         4,
+			SliceSize:      8192,
+			SliceThreshold: 102400,
+			Recurse:        true,
+			Silent:         true,
+			HideProgress:   true,
+			ShowTop:        10,
+			ProgressUpdate: 5,
+			WatchSettle:    50 * time.Millisecond,
+		},
+		Locations: []indexer.LocationFS{
+			{Name: tempDir, FS: os.DirFS(tempDir)},
+		},
+	}
+	if err := app.Initialise(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan WatchEvent)
+	result := make(chan error, 1)
+	go func() {
+		_, err := app.Watch(ctx, events)
+		result <- err
+	}()
+
+	next := func() WatchEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a watch event")
+		}
+		return WatchEvent{}
+	}
+
+	if event := next(); event.Kind != WatchReady || event.Files != 1 {
+		t.Fatalf("expected ready with 1 file, got %+v", event)
+	}
+
+	// unique files don't raise events, duplicates of them (even in new directories) do
+	write("uploads/unique.txt", "unique")
+	write("uploads/2024/copy.ARW", "smash")
+
+	event := next()
+	if event.Kind != WatchDuplicate || filepath.Base(event.Path) != "copy.ARW" {
+		t.Fatalf("expected copy.ARW to be a duplicate, got %+v", event)
+	}
+	if len(event.Duplicates) != 1 || filepath.Base(event.Duplicates[0]) != "DSC19841.ARW" {
+		t.Errorf("expected a duplicate of DSC19841.ARW, got %v", event.Duplicates)
+	}
+
+	cancel()
+	if err := <-result; err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	files, ok := app.Session.Dupes.Load(event.Hash)
+	if !ok || len(files.Files) != 2 {
+		t.Errorf("expected the session to have both copies, got %+v", files)
+	}
+}
@@ -130,6 +130,9 @@ func ColourFileSizeDupe(message ...any) string {
 func ColourHash(message ...any) string {
 	return pterm.Gray(message...)
 }
+func ColourFileMeta(message ...any) string {
+	return pterm.Gray(message...)
+}
 func ColourVersion(message ...any) string {
 	return pterm.LightYellow(message...)
 }
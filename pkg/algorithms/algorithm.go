@@ -0,0 +1,223 @@
+package algorithms
+
+import (
+	md5h "crypto/md5"
+	sha1h "crypto/sha1" // #nosec G505 -- SHA-1 is offered to match git & legacy manifests, not for security
+	sha256h "crypto/sha256"
+	sha512h "crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	crc32h "hash/crc32"
+	fnvh "hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
+
+	"github.com/spaolacci/murmur3"
+
+	cxHash "github.com/cespare/xxhash"
+)
+
+type Algorithm int
+
+const (
+	Xxhash Algorithm = iota
+	Fnv128
+	Fnv128a
+	Murmur3_128
+	Murmur3_64
+	Murmur3_32
+	Md5
+	Sha256
+	Sha512
+	Blake3
+	Xxh3_128
+	Sha1
+	Crc32c
+)
+
+// names of every algorithm by index, guarded by registry, see Names
+var names = map[int][]string{
+	0:  {"xxhash"},
+	1:  {"fnv128"},
+	2:  {"fnv128a", "fnv"},
+	3:  {"murmur3-128", "murmur3"},
+	4:  {"murmur3-64"},
+	5:  {"murmur3-32"},
+	6:  {"md5"},
+	7:  {"sha-256", "sha256"},
+	8:  {"sha-512", "sha512"},
+	9:  {"blake3"},
+	10: {"xxh3-128", "xxh3"},
+	11: {"sha-1", "sha1"},
+	12: {"crc32c"},
+}
+
+var castagnoli = crc32h.MakeTable(crc32h.Castagnoli)
+
+var (
+	// registry guards names & constructors, algorithms can be registered by library users
+	registry     sync.RWMutex
+	next         = Algorithm(len(names))
+	constructors = map[Algorithm]func() hash.Hash{
+		Xxhash:      func() hash.Hash { return cxHash.New() },
+		Fnv128:      fnvh.New128,
+		Fnv128a:     fnvh.New128a,
+		Murmur3_128: func() hash.Hash { return murmur3.New128() },
+		Murmur3_64:  func() hash.Hash { return murmur3.New64() },
+		Murmur3_32:  func() hash.Hash { return murmur3.New32() },
+		Md5:         md5h.New,
+		Sha256:      sha256h.New,
+		Sha512:      sha512h.New,
+		Blake3:      func() hash.Hash { return blake3.New() },
+		Xxh3_128:    func() hash.Hash { return &xxh3Digest128{xxh3.New()} },
+		Sha1:        sha1h.New, // #nosec G401 -- see import
+		Crc32c:      func() hash.Hash { return crc32h.New(castagnoli) },
+	}
+)
+
+// Register Adds a hash algorithm under a name & aliases (accepted by --algorithm), returning
+// the Algorithm to use it by. Names are matched like Parse & must not already be taken.
+// Register before scanning, eg. from init, for a keyed hash:
+//
+//	tenant, err := algorithms.Register(func() hash.Hash { return hmac.New(sha256.New, key) }, "tenant-hmac")
+func Register(constructor func() hash.Hash, name string, aliases ...string) (Algorithm, error) {
+	if constructor == nil {
+		return 0, errors.New("hash algorithm constructor is required")
+	}
+	aliases = append([]string{name}, aliases...)
+	registry.Lock()
+	defer registry.Unlock()
+	for _, n := range aliases {
+		if normalise(n) == "" {
+			return 0, errors.New("hash algorithm names cannot be empty")
+		}
+		if algorithm, ok := parse(n); ok {
+			return 0, fmt.Errorf("hash algorithm %q is already registered as %s", n, names[int(algorithm)][0])
+		}
+	}
+	// IDs are never reused, so an Algorithm kept after Unregister can't become another
+	algorithm := next
+	next++
+	names[int(algorithm)] = aliases
+	constructors[algorithm] = constructor
+	return algorithm, nil
+}
+
+// Unregister Removes an algorithm added by Register, built-in algorithms can't be removed.
+func Unregister(algorithm Algorithm) error {
+	if algorithm <= Crc32c {
+		return fmt.Errorf("hash algorithm %s is built-in", algorithm)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	delete(names, int(algorithm))
+	delete(constructors, algorithm)
+	return nil
+}
+
+// Names Returns a snapshot of the names & aliases of every algorithm by index,
+// used by the CLI to validate --algorithm. Algorithms registered after won't be in it.
+func Names() map[int][]string {
+	registry.RLock()
+	defer registry.RUnlock()
+	snapshot := make(map[int][]string, len(names))
+	for index, aliases := range names {
+		snapshot[index] = slices.Clone(aliases)
+	}
+	return snapshot
+}
+
+// All Returns every built-in & registered algorithm in order.
+func All() []Algorithm {
+	registry.RLock()
+	defer registry.RUnlock()
+	all := make([]Algorithm, 0, len(constructors))
+	for algorithm := range constructors {
+		all = append(all, algorithm)
+	}
+	slices.Sort(all)
+	return all
+}
+
+// Available Returns true when the algorithm is built-in or registered.
+func (a Algorithm) Available() bool {
+	registry.RLock()
+	defer registry.RUnlock()
+	_, ok := constructors[a]
+	return ok
+}
+
+// New Instantiates a new representation of the Hash Algorithm, failing when it isn't Available.
+func (a Algorithm) New() (hash.Hash, error) {
+	registry.RLock()
+	constructor, ok := constructors[a]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("hash algorithm #%d is unavailable", a)
+	}
+	return constructor(), nil
+}
+
+// Index Returns the index for the Hash Algorithm
+func (a Algorithm) Index() int {
+	return int(a)
+}
+
+// String Returns the human-readable representation of the Hash Algorithm
+func (a Algorithm) String() string {
+	registry.RLock()
+	defer registry.RUnlock()
+	if aliases, ok := names[a.Index()]; ok {
+		return aliases[0]
+	}
+	return fmt.Sprintf("unknown(%d)", a.Index())
+}
+
+// Parse Returns the Hash Algorithm for a name or alias, ignoring case & dashes (ie. SHA256, sha-256).
+func Parse(name string) (Algorithm, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	return parse(name)
+}
+
+// Lookup Returns the Hash Algorithm for a name or alias like Parse, or an error when there isn't one.
+func Lookup(name string) (Algorithm, error) {
+	if algorithm, ok := Parse(name); ok {
+		return algorithm, nil
+	}
+	return 0, fmt.Errorf("unknown hash algorithm %q", name)
+}
+
+func parse(name string) (Algorithm, bool) {
+	name = normalise(name)
+	for index, aliases := range names {
+		for _, alias := range aliases {
+			if normalise(alias) == name {
+				return Algorithm(index), true
+			}
+		}
+	}
+	return Xxhash, false
+}
+
+func normalise(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "-", ""))
+}
+
+// xxh3Digest128 Adapts xxh3 so Sum returns the 128-bit hash, the Hasher defaults to 64-bits.
+type xxh3Digest128 struct {
+	*xxh3.Hasher
+}
+
+func (d *xxh3Digest128) Size() int {
+	return 16
+}
+func (d *xxh3Digest128) Sum(b []byte) []byte {
+	sum := d.Sum128().Bytes()
+	return append(b, sum[:]...)
+}
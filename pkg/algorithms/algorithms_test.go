@@ -0,0 +1,484 @@
+package algorithms
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"strings"
+	"testing"
+)
+
+func mustNew(tb testing.TB, algorithm Algorithm) hash.Hash {
+	tb.Helper()
+	h, err := algorithm.New()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return h
+}
+
+func TestAlgorithmNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		algo     Algorithm
+		wantType string
+	}{
+		{
+			name:     "xxhash",
+			algo:     Xxhash,
+			wantType: "*xxhash.digest",
+		},
+		{
+			name:     "murmur3_32",
+			algo:     Murmur3_32,
+			wantType: "*murmur3.digest32",
+		},
+		{
+			name:     "murmur3_128",
+			algo:     Murmur3_128,
+			wantType: "*murmur3.digest128",
+		},
+		{
+			name:     "sha256",
+			algo:     Sha256,
+			wantType: "*sha256.digest",
+		},
+		{
+			name:     "md5",
+			algo:     Md5,
+			wantType: "*md5.digest",
+		},
+		{
+			name:     "blake3",
+			algo:     Blake3,
+			wantType: "*blake3.Hasher",
+		},
+		{
+			name:     "xxh3_128",
+			algo:     Xxh3_128,
+			wantType: "*algorithms.xxh3Digest128",
+		},
+		{
+			name:     "sha1",
+			algo:     Sha1,
+			wantType: "*sha1.digest",
+		},
+		{
+			name:     "crc32c",
+			algo:     Crc32c,
+			wantType: "*crc32.digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := mustNew(t, tt.algo)
+			if h == nil {
+				t.Fatal("New() returned nil")
+			}
+
+			// Verify it implements hash.Hash
+			if _, ok := h.(hash.Hash); !ok {
+				t.Error("returned value does not implement hash.Hash")
+			}
+		})
+	}
+}
+
+func TestAlgorithmConsistency(t *testing.T) {
+	// Test that algorithms produce consistent results
+	testData := [][]byte{
+		[]byte(""),
+		[]byte("a"),
+		[]byte("abc"),
+		[]byte("The quick brown fox jumps over the lazy dog"),
+		bytes.Repeat([]byte("x"), 1024),
+	}
+
+	algorithms := []Algorithm{
+		Xxhash,
+		Murmur3_32,
+		Murmur3_128,
+		Sha256,
+		Md5,
+		Blake3,
+		Xxh3_128,
+		Sha1,
+		Crc32c,
+	}
+
+	for _, algo := range algorithms {
+		t.Run(algo.String(), func(t *testing.T) {
+			for i, data := range testData {
+				// Hash the data twice and ensure results match
+				h1 := mustNew(t, algo)
+				h1.Write(data)
+				sum1 := h1.Sum(nil)
+
+				h2 := mustNew(t, algo)
+				h2.Write(data)
+				sum2 := h2.Sum(nil)
+
+				if !bytes.Equal(sum1, sum2) {
+					t.Errorf("inconsistent hash for data[%d]: %x != %x", i, sum1, sum2)
+				}
+			}
+		})
+	}
+}
+
+func TestAlgorithmReset(t *testing.T) {
+	algorithms := []Algorithm{
+		Xxhash,
+		Murmur3_32,
+		Murmur3_128,
+		Sha256,
+		Md5,
+		Blake3,
+		Xxh3_128,
+		Sha1,
+		Crc32c,
+	}
+
+	data1 := []byte("first data")
+	data2 := []byte("second data")
+
+	for _, algo := range algorithms {
+		t.Run(algo.String(), func(t *testing.T) {
+			h := mustNew(t, algo)
+
+			// Hash first data
+			h.Write(data1)
+			sum1 := h.Sum(nil)
+
+			// Reset and hash second data
+			h.Reset()
+			h.Write(data2)
+			sum2 := h.Sum(nil)
+
+			// Hash second data with fresh hasher
+			h2 := mustNew(t, algo)
+			h2.Write(data2)
+			sum2Fresh := h2.Sum(nil)
+
+			if bytes.Equal(sum1, sum2) {
+				t.Error("reset did not clear state")
+			}
+
+			if !bytes.Equal(sum2, sum2Fresh) {
+				t.Error("reset hasher produced different result than fresh hasher")
+			}
+		})
+	}
+}
+
+func TestAlgorithmIncrementalHashing(t *testing.T) {
+	// Test that incremental writes produce same result as single write
+	algorithms := []Algorithm{
+		Xxhash,
+		Murmur3_32,
+		Murmur3_128,
+		Sha256,
+		Md5,
+		Blake3,
+		Xxh3_128,
+		Sha1,
+		Crc32c,
+	}
+
+	fullData := []byte("The quick brown fox jumps over the lazy dog")
+
+	for _, algo := range algorithms {
+		t.Run(algo.String(), func(t *testing.T) {
+			// Single write
+			h1 := mustNew(t, algo)
+			h1.Write(fullData)
+			sum1 := h1.Sum(nil)
+
+			// Incremental writes
+			h2 := mustNew(t, algo)
+			h2.Write(fullData[:10])
+			h2.Write(fullData[10:20])
+			h2.Write(fullData[20:])
+			sum2 := h2.Sum(nil)
+
+			if !bytes.Equal(sum1, sum2) {
+				t.Errorf("incremental hashing produced different result: %x != %x", sum1, sum2)
+			}
+		})
+	}
+}
+
+func TestAlgorithmKnownValues(t *testing.T) {
+	// Test against known hash values
+	data := []byte("test")
+
+	tests := []struct {
+		algo     Algorithm
+		expected string
+	}{
+		{Md5, "098f6bcd4621d373cade4e832627b4f6"},
+		{Sha256, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{Sha1, "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"},
+		{Crc32c, "86a072c0"},
+		// Note: xxhash and murmur3 values depend on seed/implementation
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algo.String(), func(t *testing.T) {
+			h := mustNew(t, tt.algo)
+			h.Write(data)
+			sum := h.Sum(nil)
+
+			// Skip non-deterministic algorithms
+			if tt.expected == "" {
+				return
+			}
+
+			got := fmt.Sprintf("%x", sum)
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAlgorithmKnownEmptyValues(t *testing.T) {
+	// Reference vectors for the empty input, from the BLAKE3 & xxHash test suites
+	tests := []struct {
+		algo     Algorithm
+		expected string
+	}{
+		{Blake3, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{Xxh3_128, "99aa06d3014798d86001c324468d497f"},
+		{Sha1, "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		{Crc32c, "00000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algo.String(), func(t *testing.T) {
+			h := mustNew(t, tt.algo)
+			sum := h.Sum(nil)
+
+			if len(sum) != h.Size() {
+				t.Errorf("expected %d byte sum, got %d", h.Size(), len(sum))
+			}
+			got := fmt.Sprintf("%x", sum)
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Algorithm
+		ok       bool
+	}{
+		{name: "sha256", expected: Sha256, ok: true},
+		{name: "SHA256", expected: Sha256, ok: true},
+		{name: "sha-256", expected: Sha256, ok: true},
+		{name: "MD5", expected: Md5, ok: true},
+		{name: "murmur3", expected: Murmur3_128, ok: true},
+		{name: "xxh3", expected: Xxh3_128, ok: true},
+		{name: "tiger", expected: Xxhash, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, ok := Parse(tt.name)
+			if ok != tt.ok || actual != tt.expected {
+				t.Errorf("expected %v (%t), got %v (%t)", tt.expected, tt.ok, actual, ok)
+			}
+		})
+	}
+}
+
+func BenchmarkAlgorithms(b *testing.B) {
+	sizes := []int{
+		64,
+		1024,
+		16 * 1024,
+		1024 * 1024,
+	}
+
+	algorithms := []Algorithm{
+		Xxhash,
+		Murmur3_32,
+		Murmur3_128,
+		Sha256,
+		Md5,
+		Blake3,
+		Xxh3_128,
+		Sha1,
+		Crc32c,
+	}
+
+	for _, size := range sizes {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i % 256)
+		}
+
+		for _, algo := range algorithms {
+			b.Run(algo.String()+"_"+humanizeBytes(size), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					h := mustNew(b, algo)
+					h.Write(data)
+					_ = h.Sum(nil)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkAlgorithmsParallel(b *testing.B) {
+	data := make([]byte, 1024*1024) // 1MB
+	for i := range data {
+		data[i] = byte(i % 256)
+	}
+
+	algorithms := []Algorithm{
+		Xxhash,
+		Murmur3_32,
+		Murmur3_128,
+		Sha256,
+		Md5,
+		Blake3,
+		Xxh3_128,
+		Sha1,
+		Crc32c,
+	}
+
+	for _, algo := range algorithms {
+		b.Run(algo.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					h := mustNew(b, algo)
+					h.Write(data)
+					_ = h.Sum(nil)
+				}
+			})
+		})
+	}
+}
+
+func humanizeBytes(b int) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := unit, 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%d%cB", b/div, "KMGTPE"[exp])
+}
+
+func TestRegister(t *testing.T) {
+	key := []byte("tenant-1984")
+	name := "tenant-hmac-" + t.Name()
+	tenant, err := Register(func() hash.Hash { return hmac.New(sha256.New, key) }, name, "tenant-"+t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := Unregister(tenant); err != nil {
+			t.Error(err)
+		}
+	})
+	if !tenant.Available() || tenant.String() != name {
+		t.Errorf("expected %s to be available, got %s", name, tenant)
+	}
+	if algorithm, ok := Parse(strings.ToUpper("tenant-" + t.Name())); !ok || algorithm != tenant {
+		t.Errorf("expected alias to parse as %v, got %v", tenant, algorithm)
+	}
+
+	h := mustNew(t, tenant)
+	h.Write([]byte("smash"))
+	expected := hmac.New(sha256.New, key)
+	expected.Write([]byte("smash"))
+	if !bytes.Equal(h.Sum(nil), expected.Sum(nil)) {
+		t.Error("expected the registered constructor to be used")
+	}
+
+	tests := []struct {
+		constructor func() hash.Hash
+		name        string
+		aliases     []string
+	}{
+		{name: "sha-256", constructor: sha256.New},
+		{name: "other", aliases: []string{"tenant-" + t.Name()}, constructor: sha256.New},
+		{name: "", constructor: sha256.New},
+		{name: "nothing"},
+	}
+	for _, tt := range tests {
+		if _, err := Register(tt.constructor, tt.name, tt.aliases...); err == nil {
+			t.Errorf("expected registering %q %v to fail", tt.name, tt.aliases)
+		}
+	}
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	if _, err := Lookup("sha3-1984"); err == nil {
+		t.Error("expected an error for an unknown name")
+	}
+	unknown := Algorithm(1984)
+	if unknown.Available() {
+		t.Error("expected an unregistered algorithm to be unavailable")
+	}
+	if unknown.String() != "unknown(1984)" {
+		t.Errorf("expected unknown(1984), got %s", unknown)
+	}
+	if h, err := unknown.New(); err == nil || h != nil {
+		t.Error("expected New to fail rather than fall back to xxhash")
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	name := "unregister-" + t.Name()
+	algorithm, err := Register(sha256.New, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Unregister(algorithm); err != nil {
+		t.Fatal(err)
+	}
+	if algorithm.Available() {
+		t.Error("expected an unregistered algorithm to be unavailable")
+	}
+	if _, ok := Parse(name); ok {
+		t.Errorf("expected %s to be free again", name)
+	}
+	// IDs aren't reused, so a stale Algorithm can't hash with another's constructor
+	again, err := Register(sha256.New, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Unregister(again) })
+	if again == algorithm {
+		t.Errorf("expected a new ID, got %d again", again)
+	}
+	if err := Unregister(Sha256); err == nil {
+		t.Error("expected built-in algorithms to stay registered")
+	}
+}
+
+func TestNamesIsASnapshot(t *testing.T) {
+	names := Names()
+	names[int(Sha256)][0] = "changed"
+	if Sha256.String() != "sha-256" {
+		t.Errorf("expected sha-256, got %s", Sha256)
+	}
+}
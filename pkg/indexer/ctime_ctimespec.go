@@ -0,0 +1,12 @@
+//go:build darwin || freebsd || netbsd
+
+package indexer
+
+import (
+	"syscall"
+	"time"
+)
+
+func changeTime(st *syscall.Stat_t) time.Time {
+	return time.Unix(st.Ctimespec.Unix())
+}
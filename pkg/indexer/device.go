@@ -0,0 +1,22 @@
+package indexer
+
+// Device identifies the physical device a location or file lives on.
+type Device struct {
+	ID         uint64
+	Rotational bool
+	Known      bool
+}
+
+// DetectDevice Returns the device the path lives on, or an unknown Device where
+// the platform or file system can't tell us.
+func DetectDevice(path string) Device {
+	id, ok := deviceID(path)
+	if !ok {
+		return Device{}
+	}
+	return Device{
+		ID:         id,
+		Rotational: isRotational(id),
+		Known:      true,
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package indexer
+
+func deviceID(path string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package indexer
+
+import (
+	"os"
+	"syscall"
+)
+
+func deviceID(path string) (uint64, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		// #nosec G115 -- Dev is signed on some platforms, the bits are what matter
+		return uint64(st.Dev), true
+	}
+	return 0, false
+}
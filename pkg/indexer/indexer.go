@@ -1,6 +1,7 @@
 package indexer
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 	"path/filepath"
@@ -14,6 +15,7 @@ type FileFS struct {
 	Name       string
 	Location   string
 	FullName   string
+	Meta       FileMeta
 }
 type IndexerConfig struct {
 	dirMatcher  *regexp.Regexp
@@ -29,9 +31,34 @@ type IndexerConfig struct {
 	IgnoreSystemItems bool
 }
 type WalkConfig struct {
+	// OnSkip is called with every file or directory left out of the walk, with
+	// the error for those that couldn't be read
+	OnSkip func(path string, dir bool, reason SkipReason, err error)
+	// Dir to start walking from within the location, defaults to its root
+	Dir     string
 	Recurse bool
 }
 
+// SkipReason Says why a file or directory wasn't smashed.
+type SkipReason string
+
+const (
+	SkipPermission SkipReason = "permission"
+	SkipExcluded   SkipReason = "excluded"
+	SkipHidden     SkipReason = "hidden"
+	SkipSystem     SkipReason = "system"
+	// SkipSize & SkipSpecial are for files left out once they're indexed, by
+	// --min-size & --max-size or for being a pipe, socket, device or symlink
+	SkipSize    SkipReason = "size"
+	SkipSpecial SkipReason = "special"
+)
+
+func (options WalkConfig) skip(path string, dir bool, reason SkipReason, err error) {
+	if options.OnSkip != nil {
+		options.OnSkip(path, dir, reason, err)
+	}
+}
+
 func New() *IndexerConfig {
 	return &IndexerConfig{
 		IgnoreHiddenItems: true,
@@ -66,51 +93,100 @@ func NewConfigured(excludeDirFilter []string, excludeFileFilter []string, ignore
 	return indexer
 }
 
-func (config *IndexerConfig) WalkDirectory(f fs.FS, root string, options WalkConfig, files chan *FileFS) error {
+// WalkDirectory Sends every file of the location to files, stopping with the context's error when it's done.
+func (config *IndexerConfig) WalkDirectory(ctx context.Context, f fs.FS, root string, options WalkConfig, files chan *FileFS) error {
 	const RootDir = "."
-	walkErr := fs.WalkDir(f, RootDir, func(path string, d fs.DirEntry, err error) error {
+	start := RootDir
+	if options.Dir != "" {
+		start = options.Dir
+	}
+	walkErr := fs.WalkDir(f, start, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			if errors.Is(err, fs.ErrPermission) {
+				options.skip(path, d == nil || d.IsDir(), SkipPermission, err)
 				return fs.SkipDir
 			}
 			return err
 		}
 		name := filepath.Clean(d.Name())
 
-		isHiddenObj := config.IgnoreHiddenItems && config.isHidden(name)
-
 		if d.IsDir() {
 
-			isIgnoreDir := config.IgnoreSystemItems && config.isIgnored(name, config.excludeSysDirFilter)
-			isExludeDir := len(config.ExcludeDirFilter) > 0 && config.dirMatcher.MatchString(path)
-			dontRecurse := !options.Recurse && name != RootDir
-
-			if isHiddenObj || isIgnoreDir || isExludeDir || dontRecurse {
+			if reason := config.DirSkipReason(path, name); reason != "" {
+				options.skip(path, true, reason, nil)
+				return fs.SkipDir
+			}
+			if !options.Recurse && path != start {
 				return fs.SkipDir
 			}
 
 		} else {
 
-			isIgnoreFile := config.IgnoreSystemItems && config.isIgnored(name, config.excludeSysFileFilter)
-			isExludeFile := len(config.ExcludeFileFilter) > 0 && config.fileMatcher.MatchString(name)
-
-			if isHiddenObj || isIgnoreFile || isExludeFile {
+			if reason := config.FileSkipReason(name); reason != "" {
+				options.skip(path, false, reason, nil)
 				return nil
 			}
 
-			files <- &FileFS{
+			file := &FileFS{
 				FileSystem: &f,
 				Path:       path,
 				Name:       name,
 				Location:   root,
 				FullName:   filepath.Join(root, path),
 			}
+			if fi, err := d.Info(); err == nil {
+				file.Meta = ReadMeta(fi)
+			}
+			select {
+			case files <- file:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 		return nil
 	})
 	return walkErr
 }
 
+// ExcludesDir Returns true when the directory is hidden, a system directory or matches --exclude-dir.
+func (config *IndexerConfig) ExcludesDir(path string, name string) bool {
+	return config.DirSkipReason(path, name) != ""
+}
+
+// ExcludesFile Returns true when the file is hidden, a system file or matches --exclude-file.
+func (config *IndexerConfig) ExcludesFile(name string) bool {
+	return config.FileSkipReason(name) != ""
+}
+
+// DirSkipReason Returns why the directory is excluded, or "" when it isn't.
+func (config *IndexerConfig) DirSkipReason(path string, name string) SkipReason {
+	switch {
+	case config.IgnoreHiddenItems && config.isHidden(name):
+		return SkipHidden
+	case config.IgnoreSystemItems && config.isIgnored(name, config.excludeSysDirFilter):
+		return SkipSystem
+	case len(config.ExcludeDirFilter) > 0 && config.dirMatcher.MatchString(path):
+		return SkipExcluded
+	}
+	return ""
+}
+
+// FileSkipReason Returns why the file is excluded, or "" when it isn't.
+func (config *IndexerConfig) FileSkipReason(name string) SkipReason {
+	switch {
+	case config.IgnoreHiddenItems && config.isHidden(name):
+		return SkipHidden
+	case config.IgnoreSystemItems && config.isIgnored(name, config.excludeSysFileFilter):
+		return SkipSystem
+	case len(config.ExcludeFileFilter) > 0 && config.fileMatcher.MatchString(name):
+		return SkipExcluded
+	}
+	return ""
+}
+
 func (config *IndexerConfig) isIgnored(item string, collection []string) bool {
 	for _, v := range collection {
 		if strings.EqualFold(v, item) {
@@ -1,9 +1,16 @@
 package indexer
 
 import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"testing"
 	"testing/fstest"
+	"time"
 )
 
 func TestIndexDirectoryWithFilesInRoot(t *testing.T) {
@@ -50,6 +57,26 @@ func TestIndexDirectoryWithFilesAcrossFolders(t *testing.T) {
 	}
 }
 
+func TestIndexDirectoryFromSubfolder(t *testing.T) {
+	mockFiles := []string{
+		"DSC19841.ARW",
+		"subfolder-1/DSC19845.ARW",
+		"subfolder-1/nested/DSC19846.ARW",
+		"subfolder-2/DSC19847.ARW",
+	}
+
+	walkOptions := WalkConfig{Dir: "subfolder-1", Recurse: false}
+	walkedFiles := walkDirectoryTestRunner(mockFiles, nil, nil, true, walkOptions, t)
+
+	// paths stay relative to the location, not the folder walked
+	expected := []string{"subfolder-1/DSC19845.ARW"}
+	actual := walkedFiles
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v files", expected, actual)
+	}
+}
+
 func TestIndexDirectoryWithDirExclusionsNoRecurse(t *testing.T) {
 	exclude_dir := []string{}
 	exclude_file := []string{}
@@ -308,7 +335,7 @@ func walkDirectoryTestRunner(files []string, excludeDir []string, excludeFiles [
 	go func() {
 		defer close(ch)
 		indexer := NewConfigured(excludeDir, excludeFiles, ignoreHiddenItems, true)
-		err := indexer.WalkDirectory(fs, fr, wo, ch)
+		err := indexer.WalkDirectory(context.Background(), fs, fr, wo, ch)
 		if err != nil {
 			t.Errorf("WalkDirectory returned an error: %v", err)
 		}
@@ -323,3 +350,144 @@ func createMockFS(files []string) fstest.MapFS {
 	}
 	return fs
 }
+
+func TestDetectDeviceOfMissingPath(t *testing.T) {
+	device := DetectDevice(filepath.Join(t.TempDir(), "does-not-exist"))
+
+	if device.Known {
+		t.Errorf("expected unknown device, got %+v", device)
+	}
+}
+
+func TestWalkDirectoryCapturesMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+	filename := filepath.Join(tempDir, "DSC19841.ARW")
+	if err := os.WriteFile(filename, []byte("raw"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatalf("failed to set file times: %v", err)
+	}
+
+	files := make(chan *FileFS, 1)
+	walkOptions := WalkConfig{Recurse: true}
+	if err := New().WalkDirectory(context.Background(), os.DirFS(tempDir), tempDir, walkOptions, files); err != nil {
+		t.Fatalf("unexpected walk error %v", err)
+	}
+	close(files)
+
+	file := <-files
+	if file == nil {
+		t.Fatal("expected file to be indexed")
+	}
+	if !file.Meta.ModTime.Equal(modTime) {
+		t.Errorf("expected mod time %v, got %v", modTime, file.Meta.ModTime)
+	}
+	if !file.Meta.Mode.IsRegular() {
+		t.Errorf("expected regular file mode, got %v", file.Meta.Mode)
+	}
+	if runtime.GOOS == "windows" {
+		if file.Meta.UID != UnknownID || file.Meta.GID != UnknownID {
+			t.Errorf("expected unknown owner on windows, got %+v", file.Meta)
+		}
+		return
+	}
+	if file.Meta.Inode == 0 {
+		t.Errorf("expected inode to be resolved, got %+v", file.Meta)
+	}
+	if file.Meta.Links != 1 {
+		t.Errorf("expected 1 link, got %d", file.Meta.Links)
+	}
+	if file.Meta.UID != os.Getuid() || file.Meta.GID != os.Getgid() {
+		t.Errorf("expected owner %d:%d, got %d:%d", os.Getuid(), os.Getgid(), file.Meta.UID, file.Meta.GID)
+	}
+	if file.Meta.ChangeTime.IsZero() {
+		t.Error("expected change time to be resolved")
+	}
+	if device := DetectDevice(tempDir); !device.Known {
+		t.Errorf("expected device of %s to be known", tempDir)
+	}
+}
+
+func TestWalkDirectoryStopsWhenCancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"DSC19841.ARW", "DSC19842.ARW"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("raw"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	files := make(chan *FileFS)
+	done := make(chan error)
+	go func() {
+		done <- New().WalkDirectory(ctx, os.DirFS(tempDir), tempDir, WalkConfig{Recurse: true}, files)
+	}()
+
+	// nothing reads the second file, the walk must not block on it once cancelled
+	<-files
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+// lockedFS Fails to read a directory with a permission error, like one smash can't list.
+type lockedFS struct {
+	fstest.MapFS
+	dir string
+}
+
+func (l lockedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == l.dir {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: fs.ErrPermission}
+	}
+	return l.MapFS.ReadDir(name)
+}
+
+func TestWalkDirectoryRecordsSkipped(t *testing.T) {
+	mockFS := lockedFS{MapFS: createMockFS([]string{
+		"DSC19841.ARW",
+		".DSC19842.ARW",
+		"thumbs.db",
+		"exclude.me",
+		"$RECYCLE.BIN/DSC19843.ARW",
+		"private/DSC19844.ARW",
+	}), dir: "private"}
+
+	skipped := make(map[string]SkipReason)
+	dirs := make(map[string]bool)
+	walkOptions := WalkConfig{Recurse: true, OnSkip: func(path string, dir bool, reason SkipReason, err error) {
+		skipped[path] = reason
+		dirs[path] = dir
+		if (reason == SkipPermission) != (err != nil) {
+			t.Errorf("expected an error only for unreadable paths, got %v for %s", err, path)
+		}
+	}}
+
+	indexer := NewConfigured(nil, []string{"exclude.me"}, true, true)
+	files := make(chan *FileFS)
+	go func() {
+		defer close(files)
+		if err := indexer.WalkDirectory(context.Background(), mockFS, "/mnt/c", walkOptions, files); err != nil {
+			t.Errorf("WalkDirectory returned an error: %v", err)
+		}
+	}()
+	for range files {
+	}
+
+	expected := map[string]SkipReason{
+		".DSC19842.ARW": SkipHidden,
+		"thumbs.db":     SkipSystem,
+		"exclude.me":    SkipExcluded,
+		"$RECYCLE.BIN":  SkipSystem,
+		"private":       SkipPermission,
+	}
+	if !reflect.DeepEqual(skipped, expected) {
+		t.Errorf("expected %v, got %v", expected, skipped)
+	}
+	if !dirs["private"] || !dirs["$RECYCLE.BIN"] || dirs["thumbs.db"] {
+		t.Errorf("expected only private & $RECYCLE.BIN to be directories, got %v", dirs)
+	}
+}
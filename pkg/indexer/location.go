@@ -11,15 +11,20 @@
 )
 
 type LocationFS struct {
-	fs.FS        // embed the original fs.FS type
-	Name  string // add a new field
-	Kind  Kind
+	fs.FS         // embed the original fs.FS type
+	Name   string // add a new field
+	Device Device
+	Kind   Kind
 }
 
 func NewLocationFS(kind Kind, name string, fsys fs.FS) *LocationFS {
-	return &LocationFS{
+	location := &LocationFS{
 		FS:   fsys,
 		Name: name,
 		Kind: kind,
 	}
+	if kind == Local {
+		location.Device = DetectDevice(name)
+	}
+	return location
 }
@@ -0,0 +1,60 @@
+package indexer
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// UnknownID is used for the uid & gid of files on platforms without owners.
+const UnknownID = -1
+
+// FileMeta is the metadata of a file captured at index time, so reports can
+// help decide which copy of a duplicate to keep without stat'ing it again.
+type FileMeta struct {
+	ModTime time.Time
+	// ChangeTime is the inode change time, zero where the platform doesn't have one
+	ChangeTime time.Time
+	Owner      string
+	Size       int64
+	Inode      uint64
+	// Device is the ID of the device holding the file, zero where the platform doesn't have one
+	Device uint64
+	Links  uint64
+	UID    int
+	GID    int
+	Mode   fs.FileMode
+}
+
+var owners = xsync.NewMap[int, string]()
+
+// ReadMeta Captures the metadata of a file from its FileInfo.
+func ReadMeta(fi fs.FileInfo) FileMeta {
+	meta := FileMeta{
+		ModTime: fi.ModTime(),
+		Size:    fi.Size(),
+		Mode:    fi.Mode(),
+		UID:     UnknownID,
+		GID:     UnknownID,
+	}
+	statMeta(fi, &meta)
+	meta.Owner = ownerOf(meta.UID)
+	return meta
+}
+
+// ownerOf Resolves the username of a uid, caching lookups as most files share a handful of owners.
+func ownerOf(uid int) string {
+	if uid == UnknownID {
+		return ""
+	}
+	owner, _ := owners.LoadOrCompute(uid, func() (string, bool) {
+		if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+			return u.Username, false
+		}
+		return "", false
+	})
+	return owner
+}
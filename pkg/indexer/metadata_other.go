@@ -0,0 +1,10 @@
+//go:build !unix
+
+package indexer
+
+import (
+	"io/fs"
+)
+
+func statMeta(fi fs.FileInfo, meta *FileMeta) {
+}
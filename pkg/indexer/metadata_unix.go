@@ -0,0 +1,23 @@
+//go:build unix
+
+package indexer
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+func statMeta(fi fs.FileInfo, meta *FileMeta) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	meta.Inode = st.Ino
+	// #nosec G115 -- Dev is signed on some platforms, the bits are what matter
+	meta.Device = uint64(st.Dev)
+	// #nosec G115 -- Nlink is signed on some platforms but never negative
+	meta.Links = uint64(st.Nlink)
+	meta.UID = int(st.Uid)
+	meta.GID = int(st.Gid)
+	meta.ChangeTime = changeTime(st)
+}
@@ -0,0 +1,27 @@
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// isRotational checks sysfs for the device (or the disk a partition belongs to)
+func isRotational(id uint64) bool {
+	block, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(id), unix.Minor(id)))
+	if err != nil {
+		return false
+	}
+	for _, queue := range []string{
+		filepath.Join(block, "queue", "rotational"),
+		filepath.Join(block, "..", "queue", "rotational"),
+	} {
+		if value, err := os.ReadFile(queue); err == nil {
+			return bytes.Equal(bytes.TrimSpace(value), []byte("1"))
+		}
+	}
+	return false
+}
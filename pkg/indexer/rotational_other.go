@@ -0,0 +1,7 @@
+//go:build !linux
+
+package indexer
+
+func isRotational(id uint64) bool {
+	return false
+}
@@ -0,0 +1,297 @@
+package manifest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+type Format int
+
+const (
+	// GNU is the format of sha256sum & friends, ie. "<hash>  <path>", it doesn't record the algorithm
+	GNU Format = iota
+	// BSD is the tagged format of BSD's `sha256` & `shasum --tag`, ie. "SHA256 (<path>) = <hash>",
+	// `sha256 -r` writes the GNU format with a single space instead
+	BSD
+	// Hashdeep is the CSV format of hashdeep, ie. "<size>,<hash>,...,<path>"
+	Hashdeep
+)
+
+// Formats Used by CLI for validating --manifest-format flag
+var Formats = map[int][]string{
+	0: {"sha256sum", "gnu"},
+	1: {"bsd"},
+	2: {"hashdeep"},
+}
+
+// UnknownSize is the size of entries from formats that don't record it.
+const UnknownSize = -1
+
+const hashdeepHeader = "%%%% HASHDEEP-1.0"
+
+type Entry struct {
+	Digests map[algorithms.Algorithm]string
+	Path    string
+	Size    int64
+}
+
+type Manifest struct {
+	Entries []Entry
+	Format  Format
+}
+
+var bsdLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.*)\) = ([0-9a-fA-F]+)$`)
+
+func (f Format) String() string {
+	return Formats[int(f)][0]
+}
+
+// ErrAmbiguousHash is a GNU manifest hash more than one algorithm could have produced.
+var ErrAmbiguousHash = errors.New("ambiguous hash")
+
+// Read Parses a manifest in any of the supported formats, detected from its first line.
+// GNU manifests don't record the algorithm, it's the one that produces hashes of that
+// length, or the first of prefer that does when more than one could have.
+func Read(r io.Reader, prefer ...algorithms.Algorithm) (*Manifest, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	m := &Manifest{Format: GNU}
+	lengths := digestLengths()
+	// BSD manifests have a line per algorithm for the same path
+	seen := make(map[string]int)
+	var columns []string
+	first := true
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if first {
+			first = false
+			switch {
+			case text == hashdeepHeader:
+				m.Format = Hashdeep
+				continue
+			case bsdLine.MatchString(text):
+				m.Format = BSD
+			}
+		}
+
+		var entry Entry
+		var err error
+		switch m.Format {
+		case Hashdeep:
+			if strings.HasPrefix(text, "%%%% ") {
+				columns = strings.Split(strings.TrimPrefix(text, "%%%% "), ",")
+				continue
+			}
+			if strings.HasPrefix(text, "##") {
+				continue
+			}
+			entry, err = parseHashdeep(text, columns)
+		case BSD:
+			entry, err = parseBSD(text)
+		default:
+			if strings.HasPrefix(text, "#") {
+				continue
+			}
+			entry, err = parseGNU(text, lengths, prefer)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if entry.Path == "" {
+			// lists of bare hashes have nothing to merge by
+			m.Entries = append(m.Entries, entry)
+			continue
+		}
+		if i, ok := seen[entry.Path]; ok {
+			for algorithm, digest := range entry.Digests {
+				m.Entries[i].Digests[algorithm] = digest
+			}
+			continue
+		}
+		seen[entry.Path] = len(m.Entries)
+		m.Entries = append(m.Entries, entry)
+	}
+	return m, scanner.Err()
+}
+
+func parseGNU(text string, lengths map[int][]algorithms.Algorithm, prefer []algorithms.Algorithm) (Entry, error) {
+	escaped := strings.HasPrefix(text, "\\")
+	if escaped {
+		text = text[1:]
+	}
+	// known hash lists are often just the hashes, one per line
+	hash, path, hasPath := strings.Cut(text, " ")
+	if hasPath {
+		if path == "" {
+			return Entry{}, errors.New("expected '<hash>  <path>'")
+		}
+		// ' ' is text mode & '*' is binary mode, both hash the same on every platform we support.
+		// `sha256 -r` leaves the mode out, with a single space before the path
+		if path[0] == ' ' || path[0] == '*' {
+			path = path[1:]
+		}
+		if escaped {
+			path = unescapePath(path)
+		}
+	}
+	if !isHex(hash) {
+		return Entry{}, fmt.Errorf("unrecognised hash %q", hash)
+	}
+	algorithm, err := algorithmForLength(hash, lengths, prefer)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Path:    path,
+		Size:    UnknownSize,
+		Digests: map[algorithms.Algorithm]string{algorithm: strings.ToLower(hash)},
+	}, nil
+}
+
+func parseBSD(text string) (Entry, error) {
+	match := bsdLine.FindStringSubmatch(text)
+	if match == nil {
+		return Entry{}, errors.New("expected '<ALGORITHM> (<path>) = <hash>'")
+	}
+	algorithm, ok := algorithms.Parse(match[1])
+	if !ok {
+		return Entry{}, fmt.Errorf("unsupported algorithm %q", match[1])
+	}
+	return Entry{
+		Path:    match[2],
+		Size:    UnknownSize,
+		Digests: map[algorithms.Algorithm]string{algorithm: strings.ToLower(match[3])},
+	}, nil
+}
+
+func parseHashdeep(text string, columns []string) (Entry, error) {
+	if len(columns) < 2 || columns[0] != "size" || columns[len(columns)-1] != "filename" {
+		return Entry{}, errors.New("expected a '%%%% size,...,filename' header")
+	}
+	// filenames aren't quoted by hashdeep, so everything after the hashes is the path
+	fields := strings.SplitN(text, ",", len(columns))
+	if len(fields) != len(columns) {
+		return Entry{}, fmt.Errorf("expected %d columns, got %d", len(columns), len(fields))
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid size %q", fields[0])
+	}
+	entry := Entry{
+		Path:    fields[len(fields)-1],
+		Size:    size,
+		Digests: make(map[algorithms.Algorithm]string, len(columns)-2),
+	}
+	for i, column := range columns[1 : len(columns)-1] {
+		algorithm, ok := algorithms.Parse(column)
+		if !ok {
+			// hashdeep supports tiger & whirlpool, we can't verify those but can verify the rest
+			continue
+		}
+		entry.Digests[algorithm] = strings.ToLower(fields[i+1])
+	}
+	return entry, nil
+}
+
+// digestLengths Returns the available algorithms by the length of their hex digest.
+func digestLengths() map[int][]algorithms.Algorithm {
+	lengths := make(map[int][]algorithms.Algorithm)
+	for _, algorithm := range algorithms.All() {
+		h, err := algorithm.New()
+		if err != nil {
+			continue
+		}
+		lengths[h.Size()*2] = append(lengths[h.Size()*2], algorithm)
+	}
+	return lengths
+}
+
+// algorithmForLength Returns the algorithm of a GNU manifest hash, failing when
+// more than one could have produced it & none of them are preferred.
+func algorithmForLength(hash string, lengths map[int][]algorithms.Algorithm, prefer []algorithms.Algorithm) (algorithms.Algorithm, error) {
+	candidates := lengths[len(hash)]
+	for _, algorithm := range prefer {
+		if slices.Contains(candidates, algorithm) {
+			return algorithm, nil
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return algorithms.Xxhash, fmt.Errorf("unrecognised hash %q", hash)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, algorithm := range candidates {
+		names[i] = algorithm.String()
+	}
+	return algorithms.Xxhash, fmt.Errorf("%w, %d characters could be %s", ErrAmbiguousHash, len(hash), strings.Join(names, ", "))
+}
+
+// AlgorithmFromName Returns the algorithm a manifest's filename implies, ie. SHA256SUMS, photos.sha256 or MD5SUMS.txt
+func AlgorithmFromName(path string) (algorithms.Algorithm, bool) {
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+	if algorithm, ok := algorithms.Parse(strings.TrimPrefix(ext, ".")); ok {
+		return algorithm, true
+	}
+	stem := strings.TrimSuffix(name, ext)
+	for _, suffix := range []string{"sums", "sum"} {
+		if trimmed, ok := strings.CutSuffix(stem, suffix); ok {
+			return algorithms.Parse(trimmed)
+		}
+	}
+	return algorithms.Xxhash, false
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func escapePath(path string) (string, bool) {
+	if !strings.ContainsAny(path, "\\\n\r") {
+		return path, false
+	}
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path), true
+}
+
+func unescapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+1 < len(path) {
+			i++
+			switch path[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(path[i])
+			}
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
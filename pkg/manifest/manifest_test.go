@@ -0,0 +1,238 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+const (
+	sha256Test = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	sha1Test   = "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"
+	md5Test    = "098f6bcd4621d373cade4e832627b4f6"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		prefer   []algorithms.Algorithm
+		expected []Entry
+		format   Format
+	}{
+		{
+			name:   "sha256sum",
+			format: GNU,
+			prefer: []algorithms.Algorithm{algorithms.Sha256},
+			input:  sha256Test + "  photos/DSC19841.ARW\n" + sha256Test + " *photos/DSC19842.ARW\n",
+			expected: []Entry{
+				{Path: "photos/DSC19841.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test}},
+				{Path: "photos/DSC19842.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test}},
+			},
+		},
+		{
+			name:   "md5sum with escaped path",
+			format: GNU,
+			prefer: []algorithms.Algorithm{algorithms.Blake3, algorithms.Md5},
+			input:  "\\" + md5Test + "  photos\\\\new\\nline.ARW\n",
+			expected: []Entry{
+				{Path: "photos\\new\nline.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Md5: md5Test}},
+			},
+		},
+		{
+			name:   "sha256 -r",
+			format: GNU,
+			prefer: []algorithms.Algorithm{algorithms.Sha256},
+			input:  sha256Test + " photos/DSC19841.ARW\n" + sha256Test + " *photos/DSC19842.ARW\n",
+			expected: []Entry{
+				{Path: "photos/DSC19841.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test}},
+				{Path: "photos/DSC19842.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test}},
+			},
+		},
+		{
+			name:   "sha1sum without an algorithm",
+			format: GNU,
+			input:  sha1Test + "  photos/DSC19841.ARW\n",
+			expected: []Entry{
+				{Path: "photos/DSC19841.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha1: sha1Test}},
+			},
+		},
+		{
+			name:   "preferred algorithm of the same length",
+			format: GNU,
+			prefer: []algorithms.Algorithm{algorithms.Blake3, algorithms.Sha256},
+			input:  sha256Test + "  photos/DSC19841.ARW\n",
+			expected: []Entry{
+				{Path: "photos/DSC19841.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Blake3: sha256Test}},
+			},
+		},
+		{
+			name:   "bare hashes",
+			format: GNU,
+			prefer: []algorithms.Algorithm{algorithms.Sha256},
+			input:  sha256Test + "\n" + strings.ToUpper(sha256Test) + "\n",
+			expected: []Entry{
+				{Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test}},
+				{Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test}},
+			},
+		},
+		{
+			name:   "bsd with multiple algorithms",
+			format: BSD,
+			input:  "SHA256 (photos/DSC19841.ARW) = " + sha256Test + "\nMD5 (photos/DSC19841.ARW) = " + md5Test + "\n",
+			expected: []Entry{
+				{Path: "photos/DSC19841.ARW", Size: UnknownSize, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test, algorithms.Md5: md5Test}},
+			},
+		},
+		{
+			name:   "hashdeep",
+			format: Hashdeep,
+			input: "%%%% HASHDEEP-1.0\n%%%% size,md5,sha256,filename\n## Invoked from: /home/thushan\n## $ hashdeep -r photos\n##\n" +
+				"4," + md5Test + "," + sha256Test + ",/home/thushan/photos/DSC,19841.ARW\n",
+			expected: []Entry{
+				{Path: "/home/thushan/photos/DSC,19841.ARW", Size: 4, Digests: map[algorithms.Algorithm]string{algorithms.Md5: md5Test, algorithms.Sha256: sha256Test}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Read(strings.NewReader(tt.input), tt.prefer...)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if m.Format != tt.format {
+				t.Errorf("expected format %v, got %v", tt.format, m.Format)
+			}
+			if !reflect.DeepEqual(m.Entries, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, m.Entries)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "unknown hash length", input: "abc  photos/DSC19841.ARW\n"},
+		{name: "not hex", input: strings.Repeat("z", 64) + "  photos/DSC19841.ARW\n"},
+		{name: "unsupported bsd algorithm", input: "TIGER (photos/DSC19841.ARW) = " + md5Test + "\n"},
+		{name: "hashdeep without columns", input: "%%%% HASHDEEP-1.0\n4," + md5Test + ",photos/DSC19841.ARW\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(tt.input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadAmbiguousHash(t *testing.T) {
+	for _, hash := range []string{md5Test, sha256Test} {
+		_, err := Read(strings.NewReader(hash + "  photos/DSC19841.ARW\n"))
+		if !errors.Is(err, ErrAmbiguousHash) {
+			t.Errorf("expected %v for a %d character hash, got %v", ErrAmbiguousHash, len(hash), err)
+		}
+	}
+	// preferring an algorithm of another length doesn't pick one
+	if _, err := Read(strings.NewReader(md5Test+"\n"), algorithms.Sha256); !errors.Is(err, ErrAmbiguousHash) {
+		t.Errorf("expected %v, got %v", ErrAmbiguousHash, err)
+	}
+}
+
+func TestAlgorithmFromName(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm algorithms.Algorithm
+		ok        bool
+	}{
+		{name: "/archive/SHA256SUMS", algorithm: algorithms.Sha256, ok: true},
+		{name: "MD5SUMS.txt", algorithm: algorithms.Md5, ok: true},
+		{name: "photos.sha512", algorithm: algorithms.Sha512, ok: true},
+		{name: "photos.b3sum", ok: false},
+		{name: "photos.blake3", algorithm: algorithms.Blake3, ok: true},
+		{name: "checksums.txt", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm, ok := AlgorithmFromName(tt.name)
+			if ok != tt.ok || (ok && algorithm != tt.algorithm) {
+				t.Errorf("expected %v (%v), got %v (%v)", tt.algorithm, tt.ok, algorithm, ok)
+			}
+		})
+	}
+}
+
+func TestFormatSupports(t *testing.T) {
+	if err := GNU.Supports(algorithms.Sha256); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := GNU.Supports(algorithms.Xxhash); err == nil {
+		t.Error("expected an error for a sha256sum manifest of xxhash")
+	}
+	if err := BSD.Supports(algorithms.Xxhash); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Path: "photos/DSC19841.ARW", Size: 4, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test, algorithms.Md5: md5Test}},
+		{Path: "photos/new\nline.ARW", Size: 4, Digests: map[algorithms.Algorithm]string{algorithms.Sha256: sha256Test, algorithms.Md5: md5Test}},
+	}
+	digests := []algorithms.Algorithm{algorithms.Sha256, algorithms.Md5}
+
+	for index := range Formats {
+		format := Format(index)
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			mw := NewWriter(&buf, format, digests)
+			if err := mw.WriteHeader("/home/thushan", "smash --export-manifest=photos.txt photos"); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			for _, entry := range entries {
+				err := mw.Write(entry)
+				if format != GNU && strings.Contains(entry.Path, "\n") {
+					// only GNU tools escape paths
+					if !errors.Is(err, ErrUnsupportedPath) {
+						t.Errorf("expected unsupported path error, got %v", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			}
+			if err := mw.Flush(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			m, err := Read(&buf, algorithms.Sha256)
+			if err != nil {
+				t.Fatalf("unexpected error %v reading\n%s", err, buf.String())
+			}
+			if m.Format != format {
+				t.Errorf("expected format %v, got %v", format, m.Format)
+			}
+			for _, entry := range m.Entries {
+				if entry.Digests[algorithms.Sha256] != sha256Test {
+					t.Errorf("expected %s to have sha256 %s, got %v", entry.Path, sha256Test, entry.Digests)
+				}
+				if format != GNU && entry.Digests[algorithms.Md5] != md5Test {
+					t.Errorf("expected %s to have md5 %s, got %v", entry.Path, md5Test, entry.Digests)
+				}
+			}
+			if format == GNU && m.Entries[1].Path != entries[1].Path {
+				t.Errorf("expected path %q, got %q", entries[1].Path, m.Entries[1].Path)
+			}
+		})
+	}
+}
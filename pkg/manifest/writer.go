@@ -0,0 +1,114 @@
+package manifest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+type Writer struct {
+	w          *bufio.Writer
+	algorithms []algorithms.Algorithm
+	format     Format
+}
+
+// sumTools are the coreutils tools that check GNU manifests, a GNU manifest of any other
+// algorithm would be rejected by all of them.
+var sumTools = map[algorithms.Algorithm]string{
+	algorithms.Md5:    "md5sum",
+	algorithms.Sha1:   "sha1sum",
+	algorithms.Sha256: "sha256sum",
+	algorithms.Sha512: "sha512sum",
+}
+
+// Supports Returns an error if a manifest of this format can't be checked with the
+// algorithm, GNU manifests don't record it so it must have a coreutils tool.
+func (f Format) Supports(algorithm algorithms.Algorithm) error {
+	if f != GNU {
+		return nil
+	}
+	if _, ok := sumTools[algorithm]; !ok {
+		return fmt.Errorf("%s manifests must be md5, sha-1, sha-256 or sha-512 for coreutils to check them, not %s, use the bsd or hashdeep format", f, algorithm)
+	}
+	return nil
+}
+
+// NewWriter Writes entries in the format given, GNU manifests only have room for the first algorithm.
+func NewWriter(w io.Writer, format Format, digests []algorithms.Algorithm) *Writer {
+	if format == GNU && len(digests) > 1 {
+		digests = digests[:1]
+	}
+	return &Writer{
+		w:          bufio.NewWriter(w),
+		format:     format,
+		algorithms: digests,
+	}
+}
+
+// WriteHeader Writes the preamble hashdeep expects before any entries, other formats don't have one.
+func (mw *Writer) WriteHeader(invokedFrom string, command string) error {
+	if mw.format != Hashdeep {
+		return nil
+	}
+	columns := []string{"size"}
+	for _, algorithm := range mw.algorithms {
+		columns = append(columns, hashdeepColumn(algorithm))
+	}
+	columns = append(columns, "filename")
+
+	_, err := fmt.Fprintf(mw.w, "%s\n%%%%%%%% %s\n## Invoked from: %s\n## $ %s\n##\n",
+		hashdeepHeader, strings.Join(columns, ","), invokedFrom, command)
+	return err
+}
+
+// ErrUnsupportedPath is returned for paths with line breaks, which only GNU manifests can escape.
+var ErrUnsupportedPath = errors.New("path cannot be written to a manifest of this format")
+
+func (mw *Writer) Write(entry Entry) error {
+	if mw.format != GNU && strings.ContainsAny(entry.Path, "\n\r") {
+		return ErrUnsupportedPath
+	}
+	switch mw.format {
+	case Hashdeep:
+		fields := []string{fmt.Sprint(entry.Size)}
+		for _, algorithm := range mw.algorithms {
+			fields = append(fields, entry.Digests[algorithm])
+		}
+		fields = append(fields, entry.Path)
+		_, err := fmt.Fprintln(mw.w, strings.Join(fields, ","))
+		return err
+	case BSD:
+		for _, algorithm := range mw.algorithms {
+			if _, err := fmt.Fprintf(mw.w, "%s (%s) = %s\n", bsdTag(algorithm), entry.Path, entry.Digests[algorithm]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		path, escaped := escapePath(entry.Path)
+		prefix := ""
+		if escaped {
+			prefix = "\\"
+		}
+		_, err := fmt.Fprintf(mw.w, "%s%s  %s\n", prefix, entry.Digests[mw.algorithms[0]], path)
+		return err
+	}
+}
+
+func (mw *Writer) Flush() error {
+	return mw.w.Flush()
+}
+
+// bsdTag Returns the tag BSD tools use for an algorithm, ie. SHA256
+func bsdTag(algorithm algorithms.Algorithm) string {
+	return strings.ToUpper(strings.ReplaceAll(algorithm.String(), "-", ""))
+}
+
+// hashdeepColumn Returns the column hashdeep uses for an algorithm, ie. sha256
+func hashdeepColumn(algorithm algorithms.Algorithm) string {
+	return strings.ReplaceAll(algorithm.String(), "-", "")
+}
@@ -1,7 +1,11 @@
 package slicer
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"net/http"
+	"strings"
 	"sync"
 	"unicode/utf8"
 
@@ -10,29 +14,84 @@
 
 const MaxReadBytes = 1024
 
+const (
+	MimeUnknown   = "application/octet-stream"
+	MimeUTF8Text  = "text/plain; charset=utf-8"
+	MimeUTF16BE   = "text/plain; charset=utf-16be"
+	MimeUTF16LE   = "text/plain; charset=utf-16le"
+	mimeTextClass = "text/"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+)
+
 var detectBufferPool = sync.Pool{
 	New: func() interface{} {
 		return make([]byte, MaxReadBytes)
 	},
 }
 
-func slicingSupported(sr *io.SectionReader, size uint64) bool {
-
-	if size < utf8.UTFMax {
-		// It's a tiny file, we should full hash this puppy
-		return false
-	}
+// Content describes what was sniffed from the head of a blob.
+type Content struct {
+	MIME string
+	Text bool
+}
 
+// sniffHeader Reads up to MaxReadBytes from the head of the blob into a pooled buffer, so it's
+// only read once to sniff the content & pick a strategy. Release the buffer once it's done with.
+func sniffHeader(sr *io.SectionReader) ([]byte, func()) {
 	bufInterface := detectBufferPool.Get()
 	buf, ok := bufInterface.([]byte)
 	if !ok {
-		return false
+		return nil, func() {}
 	}
-	defer detectBufferPool.Put(buf)
+	release := func() { detectBufferPool.Put(buf) }
 
-	if _, err := sr.Read(buf); err != nil && util.IsText(buf) {
+	n, err := sr.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, release
+	}
+	return buf[:n], release
+}
+
+// sniffContent determines the MIME type of a blob from its head & whether it
+// looks like human-readable text. Byte order marks are trusted first, then
+// http.DetectContentType, with text confirmed by binary heuristics as the
+// latter only looks at the first 512 bytes.
+func sniffContent(header []byte) Content {
+	switch {
+	case len(header) == 0:
+		return Content{MIME: MimeUnknown}
+	case bytes.HasPrefix(header, bomUTF8):
+		return Content{MIME: MimeUTF8Text, Text: true}
+	case bytes.HasPrefix(header, bomUTF16BE):
+		return Content{MIME: MimeUTF16BE, Text: true}
+	case bytes.HasPrefix(header, bomUTF16LE):
+		return Content{MIME: MimeUTF16LE, Text: true}
+	}
+
+	// json, xml & scripts are all sniffed as text/*
+	mime := http.DetectContentType(header)
+	return Content{
+		MIME: mime,
+		Text: strings.HasPrefix(mime, mimeTextClass) && looksLikeText(header),
+	}
+}
+
+// looksLikeText rejects blobs with NUL bytes, invalid UTF-8 or control characters.
+func looksLikeText(header []byte) bool {
+	return bytes.IndexByte(header, 0x00) < 0 && util.IsText(header)
+}
+
+func slicingSupported(content Content, size uint64) bool {
+
+	if size < utf8.UTFMax {
+		// It's a tiny file, we should full hash this puppy
 		return false
-	} else {
-		return true
 	}
+
+	return !content.Text
 }
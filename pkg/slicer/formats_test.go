@@ -32,7 +32,7 @@ func TestSlicingSupported_OnTextBytesFile(t *testing.T) {
 	fsSize := len(binary)
 	reader := bytes.NewReader(binary)
 
-	expected := true
+	expected := false
 
 	runSlicingSupportTest(reader, int64(fsSize), expected, t)
 }
@@ -42,7 +42,7 @@ func TestSlicingSupported_OnTextStringFile(t *testing.T) {
 	fsSize := len(stexty)
 	reader := strings.NewReader(stexty)
 
-	expected := true
+	expected := false
 
 	runSlicingSupportTest(reader, int64(fsSize), expected, t)
 }
@@ -62,10 +62,70 @@ func runSlicingSupportTest(reader io.ReaderAt, size int64, expected bool, t *tes
 
 	sr := io.NewSectionReader(reader, 0, size)
 
-	actual := slicingSupported(sr, uint64(size))
+	actual := slicingSupported(detectContent(sr), uint64(size))
 
 	if actual != expected {
 		t.Errorf("expected slicing supported %t, got %t", expected, actual)
 	}
 
 }
+
+func TestSniffContent(t *testing.T) {
+	pdf, err := os.ReadFile("./artefacts/test.pdf")
+	if err != nil {
+		t.Fatalf("Unexpected io error %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		mime     string
+		header   []byte
+		expected bool
+	}{
+		{name: "empty", header: []byte{}, mime: MimeUnknown, expected: false},
+		{name: "utf-8 text", header: []byte("OMG THIS IS TEXT!"), mime: MimeUTF8Text, expected: true},
+		{name: "utf-8 bom", header: append([]byte{0xEF, 0xBB, 0xBF}, "hello"...), mime: MimeUTF8Text, expected: true},
+		{name: "utf-16be bom", header: []byte{0xFE, 0xFF, 0x00, 'h', 0x00, 'i'}, mime: MimeUTF16BE, expected: true},
+		{name: "utf-16le bom", header: []byte{0xFF, 0xFE, 'h', 0x00, 'i', 0x00}, mime: MimeUTF16LE, expected: true},
+		{name: "json", header: []byte(`{"smash": "hits"}`), mime: MimeUTF8Text, expected: true},
+		{name: "xml", header: []byte(`<?xml version="1.0"?><smash>hits</smash>`), mime: "text/xml; charset=utf-8", expected: true},
+		{name: "javascript", header: []byte(`const smash = () => "hits";`), mime: MimeUTF8Text, expected: true},
+		{name: "text with nul", header: []byte("OMG THIS IS\x00TEXT!"), mime: MimeUnknown, expected: false},
+		{name: "pdf", header: pdf[:MaxReadBytes], mime: "application/pdf", expected: false},
+		{name: "zip", header: []byte("PK\x03\x04\x14\x00\x00\x00"), mime: "application/zip", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := sniffContent(tt.header)
+
+			if actual.Text != tt.expected {
+				t.Errorf("expected text %t, got %t", tt.expected, actual.Text)
+			}
+			if actual.MIME != tt.mime {
+				t.Errorf("expected mime %s, got %s", tt.mime, actual.MIME)
+			}
+		})
+	}
+}
+
+func TestSlice_RecordsMimeType(t *testing.T) {
+	filename := "./artefacts/test.pdf"
+	binary, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Unexpected io error %v", err)
+	}
+
+	stats := sliceBytes(t, binary)
+
+	if stats.MIME != "application/pdf" {
+		t.Errorf("expected mime application/pdf, got %s", stats.MIME)
+	}
+}
+
+// detectContent sniffs up to MaxReadBytes from the head of the blob.
+func detectContent(sr *io.SectionReader) Content {
+	header, release := sniffHeader(sr)
+	defer release()
+	return sniffContent(header)
+}
@@ -0,0 +1,76 @@
+package slicer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxConcurrentReads limits the in-flight reads of a single blob when ParallelReads is enabled.
+const MaxConcurrentReads = 16
+
+// hashRegions reads & hashes each region in turn.
+func (slicer *Slicer) hashRegions(algo io.Writer, sr *io.SectionReader, regions []Region) error {
+	slice := getSliceBuffer(slicer.sliceSize)
+	if slice == nil {
+		return fmt.Errorf("slice size %w", ErrBufferTooLarge)
+	}
+	defer putSliceBuffer(slice)
+
+	for _, region := range regions {
+		if _, err := io.CopyBuffer(algo, io.NewSectionReader(sr, region.Offset, region.Size), slice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashRegionsConcurrently issues the reads for every region at once via ReadAt,
+// then hashes the buffers in region order so the hash is identical to hashRegions.
+// On high-latency storage (NFS, remote or spinning disks) this trades memory for
+// a single round-trip of latency per blob rather than one per region.
+func (slicer *Slicer) hashRegionsConcurrently(algo io.Writer, sr *io.SectionReader, regions []Region) error {
+	buffers := make([][]byte, len(regions))
+	defer func() {
+		for _, buf := range buffers {
+			putSliceBuffer(buf)
+		}
+	}()
+
+	for i, region := range regions {
+		if region.Size < 0 {
+			return errors.New("region size cannot be negative")
+		}
+		buf := getSliceBuffer(uint64(region.Size))
+		if buf == nil {
+			return fmt.Errorf("region size %w", ErrBufferTooLarge)
+		}
+		buffers[i] = buf[:region.Size]
+	}
+
+	var g errgroup.Group
+	g.SetLimit(MaxConcurrentReads)
+
+	for i, region := range regions {
+		g.Go(func() error {
+			n, err := sr.ReadAt(buffers[i], region.Offset)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			buffers[i] = buffers[i][:n]
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	for _, buf := range buffers {
+		if _, err := algo.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,18 +1,25 @@
 package slicer
 
 import (
+	"context"
 	"encoding/gob"
 	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"io/fs"
+	"math/bits"
 	"os"
+	"slices"
 	"sync"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 )
 
 type Slicer struct {
 	defaultBytes []byte
+	strategies   []Strategy
+	digests      []algorithms.Algorithm
 	slices       int
 	sliceSize    uint64
 	threshold    uint64
@@ -21,16 +28,21 @@ type Slicer struct {
 
 type SlicerStats struct {
 	SliceOffsets   map[int]int64
+	Digests        map[string][]byte
 	Filename       string
+	Strategy       string
+	MIME           string
 	Hash           []byte
 	ReaderSize     int64
 	SliceOffset    int64
 	MidSize        uint64
 	SliceSize      uint64
 	FileSize       uint64
+	BytesRead      uint64
 	Slices         int
 	EmptyFile      bool
 	IgnoredFile    bool
+	IgnoredMode    bool
 	HashedFullFile bool
 }
 
@@ -43,8 +55,22 @@ type Options struct {
 	DisableSlicing  bool
 	DisableMeta     bool
 	DisableAutoText bool
+	DisableFormats  bool
+	AdaptiveSlices  bool
+	ParallelReads   bool
 }
 
+// ErrBufferTooLarge is returned when a slice or region is too large to read into memory.
+var ErrBufferTooLarge = errors.New("too large to allocate buffer")
+
+// ErrFileChanged is returned when a file's size or modified time changed while
+// it was being hashed, ie. a log or download still being written, so its hash
+// can't be trusted.
+var ErrFileChanged = errors.New("file changed while it was being hashed")
+
+// ErrReaderUnsupported is returned for file systems whose files can't be read at an offset.
+var ErrReaderUnsupported = fmt.Errorf("the File System does not support readers: %w", errors.ErrUnsupported)
+
 const MaxSlices = 128
 const DefaultSlices = 4
 const DefaultSliceSize = 8 * 1024
@@ -90,9 +116,39 @@ func NewConfigured(algorithm algorithms.Algorithm, slices int, size, threshold u
 		threshold:    threshold,
 		algorithm:    algorithm,
 		defaultBytes: []byte{},
+		strategies:   DefaultStrategies(),
+	}
+}
+
+// UseStrategies Replaces the strategies consulted, in order, before falling back to uniform slicing.
+func (slicer *Slicer) UseStrategies(strategies ...Strategy) {
+	slicer.strategies = strategies
+}
+
+// UseDigests Computes digests with additional algorithms in the same pass as the
+// primary one, the primary hash is still what blobs are compared by.
+func (slicer *Slicer) UseDigests(additional ...algorithms.Algorithm) {
+	slicer.digests = slicer.digests[:0]
+	for _, algorithm := range additional {
+		if algorithm != slicer.algorithm && !slices.Contains(slicer.digests, algorithm) {
+			slicer.digests = append(slicer.digests, algorithm)
+		}
 	}
 }
-func (slicer *Slicer) SliceFS(fileSystem fs.FS, name string, options *Options) (SlicerStats, error) {
+
+// resolveStrategy Returns the first strategy that detects the format from the header, or uniform slicing.
+func (slicer *Slicer) resolveStrategy(header []byte, size uint64, options *Options) Strategy {
+	if options.DisableFormats || len(slicer.strategies) == 0 || len(header) == 0 {
+		return UniformStrategy{}
+	}
+	for _, strategy := range slicer.strategies {
+		if strategy.Detect(header, size) {
+			return strategy
+		}
+	}
+	return UniformStrategy{}
+}
+func (slicer *Slicer) SliceFS(ctx context.Context, fileSystem fs.FS, name string, options *Options) (SlicerStats, error) {
 
 	stats := SlicerStats{Hash: slicer.defaultBytes, Filename: name}
 	fio, ferr := fs.Stat(fileSystem, name)
@@ -112,6 +168,7 @@ func (slicer *Slicer) SliceFS(fileSystem fs.FS, name string, options *Options) (
 		shouldIgnoreFileMode(fio) ||
 		isEmptyFile {
 		stats.IgnoredFile = true
+		stats.IgnoredMode = shouldIgnoreFileMode(fio)
 		stats.EmptyFile = isEmptyFile
 		stats.Hash = nil
 		return stats, nil
@@ -128,22 +185,37 @@ func (slicer *Slicer) SliceFS(fileSystem fs.FS, name string, options *Options) (
 	}(f)
 
 	stats.FileSize = size
-	stats.Slices = slicer.slices
-	stats.SliceSize = slicer.sliceSize
 
 	if fr, ok := f.(io.ReaderAt); ok {
 		sr := io.NewSectionReader(fr, 0, fileSize)
-		err := slicer.Slice(sr, options, &stats)
-		return stats, err
+		if err := slicer.Slice(ctx, sr, options, &stats); err != nil {
+			return stats, err
+		}
+		return stats, checkUnchanged(fileSystem, name, fio)
 	} else {
-		return stats, errors.New("the File System does not support readers")
+		return stats, ErrReaderUnsupported
 	}
 }
-func (slicer *Slicer) Slice(sr *io.SectionReader, options *Options, stats *SlicerStats) error {
+
+// checkUnchanged Stats the file again after hashing, returning ErrFileChanged
+// if it's not the file that was hashed anymore.
+func checkUnchanged(fileSystem fs.FS, name string, before fs.FileInfo) error {
+	after, err := fs.Stat(fileSystem, name)
+	if err != nil {
+		return err
+	}
+	if after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) {
+		return &fs.PathError{Op: "hash", Path: name, Err: ErrFileChanged}
+	}
+	return nil
+}
+
+// Slice Hashes the blob, reading stops with the context's error when it's done.
+func (slicer *Slicer) Slice(ctx context.Context, sr *io.SectionReader, options *Options, stats *SlicerStats) error {
 
 	/*
 		Check the bytes are within the threshold for a full blob hash.
-			OR blob is determined to be text OR slicing is disabled
+			OR blob is sniffed as text (see formats.go) OR slicing is disabled
 				Hash the full blob
 		Split the blob into n slices for the bytes between the head & tail of the blob (n + 2 = totalSlices)
 			Read the head of the blob to n Bytes (slice1)
@@ -165,6 +237,9 @@ func (slicer *Slicer) Slice(sr *io.SectionReader, options *Options, stats *Slice
 				slice[3] := 1,007,616
 				\_reader := 1,015,808
 			file_tail :=  1,015,808
+
+		Formats that are understood by a Strategy (see strategy.go) choose
+		their own regions instead, eg. the central directory of a ZIP.
 	*/
 
 	srSize := sr.Size()
@@ -182,20 +257,48 @@ func (slicer *Slicer) Slice(sr *io.SectionReader, options *Options, stats *Slice
 		return nil
 	}
 
-	algo := slicer.algorithm.New()
-	algo.Reset()
+	algo, err := slicer.algorithm.New()
+	if err != nil {
+		return err
+	}
+
+	// every digest is fed the same bytes as the primary algorithm in a single read
+	var w io.Writer = algo
+	digests := make([]hash.Hash, len(slicer.digests))
+	if len(digests) > 0 {
+		writers := []io.Writer{algo}
+		for i, algorithm := range slicer.digests {
+			if digests[i], err = algorithm.New(); err != nil {
+				return err
+			}
+			writers = append(writers, digests[i])
+		}
+		w = io.MultiWriter(writers...)
+	}
 
 	stats.ReaderSize = sr.Size()
 
+	slices := slicer.slices
+	if options.AdaptiveSlices {
+		slices = AdaptiveSlices(slicer.slices, size, slicer.threshold)
+	}
+	stats.Slices = slices
+	stats.SliceSize = slicer.sliceSize
+
+	header, release := sniffHeader(sr)
+	defer release()
+	content := sniffContent(header)
+	stats.MIME = content.MIME
+
 	// checks
-	canSliceFile := !options.DisableAutoText && slicingSupported(sr, size)
-	slicesPlus2 := slicer.slices + 2
+	canSliceFile := options.DisableAutoText || slicingSupported(content, size)
+	slicesPlus2 := slices + 2
 	if slicesPlus2 < 0 {
 		return errors.New("slices overflow")
 	}
 	greaterThanMinimumFileSize := uint64(slicesPlus2)*slicer.sliceSize > size
 	greaterThanMinimumThreshold := size < slicer.threshold
-	invalidNumberOfSlices := slicer.slices <= 0
+	invalidNumberOfSlices := slices <= 0
 	// fullHash only those times we have to
 	fullHash := options.DisableSlicing ||
 		greaterThanMinimumThreshold ||
@@ -203,83 +306,52 @@ func (slicer *Slicer) Slice(sr *io.SectionReader, options *Options, stats *Slice
 		invalidNumberOfSlices ||
 		!canSliceFile
 
-	stats.HashedFullFile = fullHash
-
-	// Reset after text detection
-	_, _ = sr.Seek(0, io.SeekStart)
-
-	if fullHash {
-		if _, err := io.Copy(algo, sr); err != nil {
+	var plan Plan
+	if !fullHash {
+		strategy := slicer.resolveStrategy(header, size, options)
+		layout := Layout{Slices: slices, SliceSize: slicer.sliceSize}
+		var err error
+		if plan, err = strategy.Plan(sr, size, layout); err != nil {
 			return err
 		}
-	} else {
-		slice := getSliceBuffer(slicer.sliceSize)
-		if slice == nil {
-			return errors.New("slice size too large to allocate buffer")
-		}
-		defer putSliceBuffer(slice)
+		fullHash = plan.FullHash
+		stats.Strategy = strategy.Name()
+	} else if content.Text && !options.DisableAutoText {
+		stats.Strategy = StrategyText
+	}
 
-		midSize := size - (slicer.sliceSize * 2)
-		if slicer.slices <= 0 {
-			return errors.New("invalid number of slices")
-		}
-		divResult := midSize / uint64(slicer.slices)
-		if divResult < slicer.sliceSize {
-			return errors.New("slice offset would be negative")
-		}
-		sliceOffsetCalc := divResult - slicer.sliceSize
-		const maxInt64 = 1<<63 - 1
-		if sliceOffsetCalc > maxInt64 {
-			return errors.New("slice offset overflow")
-		}
-		sliceOffset := int64(sliceOffsetCalc)
+	stats.HashedFullFile = fullHash
 
-		stats.SliceOffset = sliceOffset
-		stats.MidSize = midSize
-		stats.SliceOffsets = make(map[int]int64, slicer.slices+2)
+	// only the content is counted, not the metadata below
+	read := &countingWriter{w: &contextWriter{ctx: ctx, w: w}}
+	defer func() { stats.BytesRead = read.n }()
 
-		// head
-		stats.SliceOffsets[0] = 0
-		if _, err := sr.Seek(0, io.SeekStart); err != nil {
-			return err
-		}
-		if _, err := sr.Read(slice); err != nil {
+	if fullHash {
+		if _, err := io.Copy(read, sr); err != nil {
 			return err
 		}
-		algo.Write(slice)
-
-		// mid-slice crisis
-		for i := 0; i < slicer.slices; i++ {
-			if offset, err := sr.Seek(sliceOffset, io.SeekCurrent); err != nil {
-				return err
-			} else {
-				stats.SliceOffsets[i+1] = offset
-			}
-			if _, err := sr.Read(slice); err != nil {
-				return err
-			}
+	} else {
+		stats.SliceOffset = plan.SliceOffset
+		stats.MidSize = plan.MidSize
+		stats.SliceOffsets = make(map[int]int64, len(plan.Regions))
 
-			algo.Write(slice)
+		for i, region := range plan.Regions {
+			stats.SliceOffsets[i] = region.Offset
 		}
 
-		// tail
-		if slicer.sliceSize > maxInt64 {
-			return errors.New("slice size too large for tail offset")
-		}
-		tailOffset := -int64(slicer.sliceSize)
-		if offset, err := sr.Seek(tailOffset, io.SeekEnd); err != nil {
-			return err
+		var err error
+		if options.ParallelReads {
+			err = slicer.hashRegionsConcurrently(read, sr, plan.Regions)
 		} else {
-			stats.SliceOffsets[len(stats.SliceOffsets)] = offset
+			err = slicer.hashRegions(read, sr, plan.Regions)
 		}
-		if _, err := sr.Read(slice); err != nil {
+		if err != nil {
 			return err
 		}
-		algo.Write(slice)
 
 		// metadata
 		if !options.DisableMeta {
-			enc := gob.NewEncoder(algo)
+			enc := gob.NewEncoder(w)
 			meta := MetaSlice{Size: size}
 			if err := enc.Encode(meta); err != nil {
 				return err
@@ -287,8 +359,53 @@ func (slicer *Slicer) Slice(sr *io.SectionReader, options *Options, stats *Slice
 		}
 	}
 	stats.Hash = algo.Sum(nil)
+	if len(digests) > 0 {
+		stats.Digests = make(map[string][]byte, len(digests)+1)
+		stats.Digests[slicer.algorithm.String()] = stats.Hash
+		for i, digest := range digests {
+			stats.Digests[slicer.digests[i].String()] = digest.Sum(nil)
+		}
+	}
 	return nil
 }
+
+// contextWriter stops a long read (eg. a full hash) as soon as the context is done.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += uint64(n)
+	return n, err
+}
+
+// AdaptiveSlices Scales the number of slices logarithmically with the size of a blob,
+// adding a slice each time the blob doubles beyond the threshold (up to MaxSlices).
+// Identical blobs are always the same size, so their slices remain comparable.
+func AdaptiveSlices(slices int, size, threshold uint64) int {
+	if slices <= 0 || threshold == 0 || size <= threshold {
+		return slices
+	}
+	adaptive := slices + bits.Len64(size/threshold) - 1
+	if adaptive > MaxSlices {
+		return MaxSlices
+	}
+	return adaptive
+}
 func shouldAnalyseBasedOnSize(fileSize, minSize, maxSize uint64) bool {
 	if minSize == DefaultMinSize && maxSize == DefaultMaxSize {
 		return true
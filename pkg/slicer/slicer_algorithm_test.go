@@ -3,13 +3,14 @@
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"io"
 	"os"
 	"strings"
 	"testing"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 )
 
 // fieldalignment: struct with 40 pointer bytes could be 24 (govet)
@@ -85,7 +86,7 @@ func runHashCheckTestsForFileSystemFile(filename string, algorithm algorithms.Al
 
 		slicer := New(algorithm)
 
-		if err := slicer.Slice(sr, options, &stats); err != nil {
+		if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 			t.Errorf("Unexpected Slicer error %v", err)
 		}
 
@@ -2,12 +2,13 @@
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
 	"testing"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 )
 
 func BenchmarkSlice(b *testing.B) {
@@ -53,7 +54,7 @@ func benchmarkSliceFullHash(b *testing.B, data []byte) {
 		sr := io.NewSectionReader(reader, 0, int64(len(data)))
 		stats := SlicerStats{}
 
-		if err := slicer.Slice(sr, options, &stats); err != nil {
+		if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -71,7 +72,7 @@ func benchmarkSliceWithSlicing(b *testing.B, data []byte) {
 		sr := io.NewSectionReader(reader, 0, int64(len(data)))
 		stats := SlicerStats{}
 
-		if err := slicer.Slice(sr, options, &stats); err != nil {
+		if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -105,7 +106,7 @@ func BenchmarkSliceAlgorithms(b *testing.B) {
 				sr := io.NewSectionReader(reader, 0, int64(len(data)))
 				stats := SlicerStats{}
 
-				if err := slicer.Slice(sr, options, &stats); err != nil {
+				if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -129,7 +130,7 @@ func BenchmarkSliceConcurrent(b *testing.B) {
 			sr := io.NewSectionReader(reader, 0, int64(len(data)))
 			stats := SlicerStats{}
 
-			if err := slicer.Slice(sr, options, &stats); err != nil {
+			if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 				b.Fatal(err)
 			}
 		}
@@ -160,7 +161,7 @@ func BenchmarkSliceMemoryAllocation(b *testing.B) {
 				sr := io.NewSectionReader(reader, 0, int64(len(data)))
 				stats := SlicerStats{}
 
-				if err := slicer.Slice(sr, options, &stats); err != nil {
+				if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -195,7 +196,7 @@ func benchmarkSliceData(b *testing.B, data []byte) {
 		sr := io.NewSectionReader(reader, 0, int64(len(data)))
 		stats := SlicerStats{}
 
-		if err := slicer.Slice(sr, options, &stats); err != nil {
+		if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -2,6 +2,7 @@
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"io/fs"
 	"math"
@@ -9,7 +10,7 @@
 	"testing"
 	"time"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 )
 
 type mockFileInfo struct {
@@ -76,7 +77,7 @@ func TestSliceFSWithNegativeFileSize(t *testing.T) {
 		},
 	}
 
-	stats, err := slicer.SliceFS(mockFS, "negative.bin", &Options{})
+	stats, err := slicer.SliceFS(context.Background(), mockFS, "negative.bin", &Options{})
 	if err == nil {
 		t.Error("expected error for negative file size, got nil")
 	}
@@ -142,7 +143,7 @@ func TestSliceWithBoundaryConditions(t *testing.T) {
 			sr := io.NewSectionReader(reader, 0, int64(len(data)))
 
 			stats := SlicerStats{}
-			err := slicer.Slice(sr, &tt.options, &stats)
+			err := slicer.Slice(context.Background(), sr, &tt.options, &stats)
 
 			if tt.wantError && err == nil {
 				t.Error("expected error but got nil")
@@ -162,7 +163,7 @@ func TestSlicesPlus2Overflow(t *testing.T) {
 	sr := io.NewSectionReader(reader, 0, int64(len(data)))
 
 	stats := SlicerStats{}
-	err := slicer.Slice(sr, &Options{}, &stats)
+	err := slicer.Slice(context.Background(), sr, &Options{}, &stats)
 
 	if err == nil {
 		t.Error("expected error for slices overflow")
@@ -0,0 +1,110 @@
+package slicer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+func TestSlice_ParallelReadsMatchSequential(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		options Options
+	}{
+		{name: "uniform 1Mb", data: randomBytes(1024000)},
+		{name: "uniform 10Mb", data: randomBytes(10 * 1024 * 1024)},
+		{name: "adaptive 10Mb", data: randomBytes(10 * 1024 * 1024), options: Options{AdaptiveSlices: true}},
+		{name: "no meta", data: randomBytes(1024000), options: Options{DisableMeta: true}},
+		{name: "video", data: buildMP4(randomBytes(2 * 1024 * 1024))},
+		{name: "archive", data: buildZip(t, 8, 256*1024)},
+	}
+
+	algos := []algorithms.Algorithm{algorithms.Xxhash, algorithms.Murmur3_128, algorithms.Sha256}
+
+	for _, tt := range tests {
+		for _, algo := range algos {
+			t.Run(tt.name+"/"+algo.String(), func(t *testing.T) {
+				sequential := tt.options
+				parallel := tt.options
+				parallel.ParallelReads = true
+
+				expected := sliceWith(t, algo, tt.data, &sequential)
+				actual := sliceWith(t, algo, tt.data, &parallel)
+
+				if actual.HashedFullFile {
+					t.Fatal("expected blob to be sliced")
+				}
+				if !bytes.Equal(expected.Hash, actual.Hash) {
+					t.Errorf("expected %x, got %x hash", expected.Hash, actual.Hash)
+				}
+			})
+		}
+	}
+}
+
+func TestSlice_ParallelReadsWithCustomSliceSize(t *testing.T) {
+	data := randomBytes(4 * 1024 * 1024)
+	slicer := NewConfigured(algorithms.Xxhash, 16, 16*1024, DefaultThreshold)
+
+	var hashes [][]byte
+	for _, parallel := range []bool{false, true} {
+		sr := io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+		stats := SlicerStats{}
+		if err := slicer.Slice(context.Background(), sr, &Options{ParallelReads: parallel}, &stats); err != nil {
+			t.Fatalf("Unexpected Slicer error %v", err)
+		}
+		hashes = append(hashes, stats.Hash)
+	}
+
+	if !bytes.Equal(hashes[0], hashes[1]) {
+		t.Errorf("expected %x, got %x hash", hashes[0], hashes[1])
+	}
+}
+
+func BenchmarkSlice_HighLatencyReads(b *testing.B) {
+	data := randomBytes(64 * 1024 * 1024)
+	reader := &latentReader{ReaderAt: bytes.NewReader(data), latency: time.Millisecond}
+	slicer := NewConfigured(algorithms.Xxhash, 32, DefaultSliceSize, DefaultThreshold)
+
+	for _, parallel := range []bool{false, true} {
+		name := "sequential"
+		if parallel {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			options := Options{ParallelReads: parallel}
+			for i := 0; i < b.N; i++ {
+				sr := io.NewSectionReader(reader, 0, int64(len(data)))
+				stats := SlicerStats{}
+				_ = slicer.Slice(context.Background(), sr, &options, &stats)
+			}
+		})
+	}
+}
+
+// latentReader simulates storage with a fixed round-trip per read
+type latentReader struct {
+	io.ReaderAt
+	latency time.Duration
+}
+
+func (l *latentReader) ReadAt(p []byte, off int64) (int, error) {
+	time.Sleep(l.latency)
+	return l.ReaderAt.ReadAt(p, off)
+}
+
+func sliceWith(t *testing.T, algo algorithms.Algorithm, data []byte, options *Options) SlicerStats {
+	t.Helper()
+	slicer := New(algo)
+	sr := io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+	stats := SlicerStats{}
+	if err := slicer.Slice(context.Background(), sr, options, &stats); err != nil {
+		t.Fatalf("Unexpected Slicer error %v", err)
+	}
+	return stats
+}
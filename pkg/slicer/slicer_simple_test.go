@@ -6,7 +6,7 @@
 	"io"
 	"testing"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -45,7 +45,7 @@ func TestSlicerBasicFunctionality(t *testing.T) {
 			sr := io.NewSectionReader(reader, 0, int64(len(tt.data)))
 
 			stats := SlicerStats{}
-			err := slicer.Slice(sr, &Options{}, &stats)
+			err := slicer.Slice(context.Background(), sr, &Options{}, &stats)
 
 			if tt.expectErr && err == nil {
 				t.Error("expected error but got nil")
@@ -73,7 +73,7 @@ func TestSlicerConcurrentUsage(t *testing.T) {
 			sr := io.NewSectionReader(reader, 0, int64(len(data)))
 			stats := SlicerStats{}
 
-			if err := slicer.Slice(sr, &Options{}, &stats); err != nil {
+			if err := slicer.Slice(context.Background(), sr, &Options{}, &stats); err != nil {
 				return err
 			}
 			return nil
@@ -3,15 +3,20 @@
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
-	"github.com/thushan/smash/internal/algorithms"
+	"github.com/thushan/smash/pkg/algorithms"
 )
 
 func TestSlice_New_OffsetMapWith1MbBlob(t *testing.T) {
@@ -31,7 +36,7 @@ func TestSlice_New_OffsetMapWith1MbBlob(t *testing.T) {
 
 	slicer := New(algorithms.Xxhash)
 
-	if err := slicer.Slice(sr, &options, &stats); err != nil {
+	if err := slicer.Slice(context.Background(), sr, &options, &stats); err != nil {
 		t.Errorf("Unexpected Slicer error %v", err)
 	}
 	// For a 1024000 byte blob with 4 segments
@@ -69,7 +74,7 @@ func TestSlice_New_With0KbBlob(t *testing.T) {
 
 	slicer := New(algorithms.Xxhash)
 
-	if err := slicer.Slice(sr, &options, &stats); err != nil {
+	if err := slicer.Slice(context.Background(), sr, &options, &stats); err != nil {
 		t.Errorf("Unexpected Slicer error %v", err)
 	}
 
@@ -102,7 +107,7 @@ func TestSlice_New_NoOffsetMapWith1KbBlob(t *testing.T) {
 
 	slicer := New(algorithms.Xxhash)
 
-	if err := slicer.Slice(sr, &options, &stats); err != nil {
+	if err := slicer.Slice(context.Background(), sr, &options, &stats); err != nil {
 		t.Errorf("Unexpected Slicer error %v", err)
 	}
 
@@ -129,7 +134,7 @@ func TestSlice_New_WithTextBinaryBlob(t *testing.T) {
 
 	slicer := New(algorithms.Xxhash)
 
-	if err := slicer.Slice(sr, &options, &stats); err != nil {
+	if err := slicer.Slice(context.Background(), sr, &options, &stats); err != nil {
 		t.Errorf("Unexpected Slicer error %v", err)
 	}
 
@@ -223,7 +228,7 @@ func TestSlice_Slice_CheckSizeThresholds(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			stats := SlicerStats{}
-			if err := slicer.Slice(sr, &tt.options, &stats); err != nil {
+			if err := slicer.Slice(context.Background(), sr, &tt.options, &stats); err != nil {
 				t.Errorf("Unexpected Slicer error %v", err)
 			}
 			if stats.IgnoredFile != tt.expected {
@@ -300,7 +305,7 @@ func TestSliceFS_SliceFS_WithDisabledOptions_ReturnsValidHash(t *testing.T) {
 
 			slicer := New(algorithm)
 
-			if stats, err := slicer.SliceFS(fsys, tt.filename, &tt.options); err != nil {
+			if stats, err := slicer.SliceFS(context.Background(), fsys, tt.filename, &tt.options); err != nil {
 				t.Errorf("Unexpected Slicer error %v", err)
 			} else {
 
@@ -345,11 +350,14 @@ func runHashAlgorithmTest(algorithm algorithms.Algorithm, t *testing.T) {
 
 	slicer := New(algorithm)
 
-	if err := slicer.Slice(sr, &options, &stats); err != nil {
+	if err := slicer.Slice(context.Background(), sr, &options, &stats); err != nil {
 		t.Errorf("Unexpected Slicer error %v", err)
 	}
 
-	hasher := algorithm.New()
+	hasher, err := algorithm.New()
+	if err != nil {
+		t.Fatal(err)
+	}
 	hasher.Write(binary)
 
 	expected := hasher.Sum(nil)
@@ -367,8 +375,170 @@ func runHashAlgorithmTest(algorithm algorithms.Algorithm, t *testing.T) {
 		t.Errorf("expected %x, got %x hash", expected, actual)
 	}
 }
+func TestAdaptiveSlices(t *testing.T) {
+	var data = []struct {
+		size      uint64
+		threshold uint64
+		slices    int
+		expected  int
+	}{
+		{size: 50 * 1024, threshold: DefaultThreshold, slices: DefaultSlices, expected: DefaultSlices},
+		{size: 200 * 1024, threshold: DefaultThreshold, slices: DefaultSlices, expected: 5},
+		{size: 1024 * 1024 * 1024, threshold: DefaultThreshold, slices: DefaultSlices, expected: 17},
+		{size: 50 * 1024 * 1024 * 1024, threshold: DefaultThreshold, slices: DefaultSlices, expected: 23},
+		{size: 50 * 1024 * 1024 * 1024, threshold: DefaultThreshold, slices: 120, expected: MaxSlices},
+		{size: 1024 * 1024, threshold: 0, slices: DefaultSlices, expected: DefaultSlices},
+	}
+
+	for _, item := range data {
+		actual := AdaptiveSlices(item.slices, item.size, item.threshold)
+		if actual != item.expected {
+			t.Errorf("expected %d slices for %d bytes, got %d", item.expected, item.size, actual)
+		}
+	}
+}
+
+func TestSlice_AdaptiveRecordsEffectiveSlices(t *testing.T) {
+	fsSize := 8 * 1024 * 1024
+	reader := bytes.NewReader(randomBytes(fsSize))
+	sr := io.NewSectionReader(reader, 0, int64(fsSize))
+
+	options := Options{AdaptiveSlices: true}
+	stats := SlicerStats{}
+	slicer := New(algorithms.Xxhash)
+
+	if err := slicer.Slice(context.Background(), sr, &options, &stats); err != nil {
+		t.Errorf("Unexpected Slicer error %v", err)
+	}
+
+	expected := AdaptiveSlices(DefaultSlices, uint64(fsSize), DefaultThreshold)
+	if stats.Slices != expected {
+		t.Errorf("expected %d slices, got %d", expected, stats.Slices)
+	}
+	if len(stats.SliceOffsets) != expected+2 {
+		t.Errorf("expected %d offsets, got %d", expected+2, len(stats.SliceOffsets))
+	}
+}
+func TestSlice_DigestsMatchEachAlgorithm(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "full hashed", data: randomBytes(1024)},
+		{name: "sliced", data: randomBytes(1024000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slicer := New(algorithms.Xxhash)
+			slicer.UseDigests(algorithms.Md5, algorithms.Xxhash, algorithms.Sha256, algorithms.Md5)
+			sr := io.NewSectionReader(bytes.NewReader(tt.data), 0, int64(len(tt.data)))
+			stats := SlicerStats{}
+			if err := slicer.Slice(context.Background(), sr, &Options{}, &stats); err != nil {
+				t.Fatalf("Unexpected Slicer error %v", err)
+			}
+
+			if len(stats.Digests) != 3 {
+				t.Fatalf("expected 3 digests, got %d", len(stats.Digests))
+			}
+			for _, algorithm := range []algorithms.Algorithm{algorithms.Xxhash, algorithms.Md5, algorithms.Sha256} {
+				expected := sliceWith(t, algorithm, tt.data, &Options{}).Hash
+				if actual := stats.Digests[algorithm.String()]; !bytes.Equal(actual, expected) {
+					t.Errorf("expected %s digest %x, got %x", algorithm, expected, actual)
+				}
+			}
+			if !bytes.Equal(stats.Hash, stats.Digests[algorithms.Xxhash.String()]) {
+				t.Errorf("expected primary hash to be unchanged by digests")
+			}
+		})
+	}
+}
+
+func TestSlice_NoDigestsWithoutAdditionalAlgorithms(t *testing.T) {
+	stats := sliceWith(t, algorithms.Xxhash, randomBytes(1024), &Options{})
+
+	if stats.Digests != nil {
+		t.Errorf("expected no digests, got %v", stats.Digests)
+	}
+}
+
+func TestSlice_RecordsBytesRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  Options
+		size     int
+		expected uint64
+	}{
+		{name: "full hashed", size: 1024, expected: 1024},
+		{name: "slicing disabled", size: 1024000, options: Options{DisableSlicing: true}, expected: 1024000},
+		{name: "sliced", size: 1024000, expected: (DefaultSlices + 2) * DefaultSliceSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := sliceWith(t, algorithms.Xxhash, randomBytes(tt.size), &tt.options)
+			if stats.BytesRead != tt.expected {
+				t.Errorf("expected %d bytes read, got %d", tt.expected, stats.BytesRead)
+			}
+		})
+	}
+}
+
 func randomBytes(length int) []byte {
 	buffer := make([]byte, length)
 	_, _ = rand.Read(buffer)
 	return buffer
 }
+
+func TestSlice_StopsWhenCancelled(t *testing.T) {
+	binary := randomBytes(1024000)
+	sr := io.NewSectionReader(bytes.NewReader(binary), 0, int64(len(binary)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats := SlicerStats{}
+	slicer := New(algorithms.Xxhash)
+	err := slicer.Slice(ctx, sr, &Options{DisableSlicing: true}, &stats)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if stats.BytesRead != 0 {
+		t.Errorf("expected 0 bytes read, got %d", stats.BytesRead)
+	}
+}
+
+// growingFS Reports the file as modified every time it's stat'd, like a download in progress.
+type growingFS struct {
+	fstest.MapFS
+	stats int
+}
+
+func (g *growingFS) Stat(name string) (fs.FileInfo, error) {
+	fi, err := g.MapFS.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	g.stats++
+	if g.stats > 1 {
+		file := *g.MapFS[name]
+		file.ModTime = fi.ModTime().Add(time.Second)
+		g.MapFS[name] = &file
+	}
+	return g.MapFS.Stat(name)
+}
+
+func TestSliceFS_DetectsChangedFiles(t *testing.T) {
+	slicer := New(algorithms.Xxhash)
+	files := fstest.MapFS{"download.iso": {Data: randomBytes(1024), ModTime: time.Now()}}
+
+	if _, err := slicer.SliceFS(context.Background(), files, "download.iso", &Options{}); err != nil {
+		t.Errorf("expected an unchanged file to hash, got %v", err)
+	}
+
+	growing := &growingFS{MapFS: files}
+	_, err := slicer.SliceFS(context.Background(), growing, "download.iso", &Options{})
+	if !errors.Is(err, ErrFileChanged) {
+		t.Errorf("expected %v, got %v", ErrFileChanged, err)
+	}
+}
@@ -0,0 +1,269 @@
+package slicer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+// Strategy decides which regions of a blob are sampled when it's sliced.
+type Strategy interface {
+	// Name identifies the strategy in SlicerStats & reports.
+	Name() string
+	// Detect reports whether the strategy understands a blob starting with header.
+	Detect(header []byte, size uint64) bool
+	// Plan returns the regions of the blob to hash, in the order they're hashed.
+	Plan(sr *io.SectionReader, size uint64, layout Layout) (Plan, error)
+}
+
+// Layout is the slice configuration a Strategy plans against.
+type Layout struct {
+	Slices    int
+	SliceSize uint64
+}
+
+// Region is a contiguous run of bytes within a blob.
+type Region struct {
+	Offset int64
+	Size   int64
+}
+
+// Plan is the outcome of a Strategy, either a full hash or a set of regions.
+type Plan struct {
+	Regions     []Region
+	SliceOffset int64
+	MidSize     uint64
+	FullHash    bool
+}
+
+const (
+	StrategyUniform = "uniform"
+	// StrategyText is recorded when text detection forces a full hash
+	StrategyText    = "text"
+	StrategyVideo   = "video"
+	StrategyArchive = "archive"
+)
+
+const (
+	// VideoHeaderSize is how much of a video container's head is hashed
+	VideoHeaderSize = 64 * 1024
+	// MaxVideoIndexSize caps the size of an MP4 'moov' box we're willing to hash
+	MaxVideoIndexSize = 4 * 1024 * 1024
+	// MaxCentralDirectorySize caps the size of a ZIP central directory we're willing to hash
+	MaxCentralDirectorySize = 32 * 1024 * 1024
+)
+
+// DefaultStrategies returns the strategies used by a new Slicer, in order of precedence.
+func DefaultStrategies() []Strategy {
+	return []Strategy{
+		ArchiveStrategy{},
+		VideoStrategy{},
+	}
+}
+
+// UniformStrategy samples the head, tail & n evenly spaced slices in between.
+type UniformStrategy struct{}
+
+func (UniformStrategy) Name() string { return StrategyUniform }
+
+func (UniformStrategy) Detect(header []byte, size uint64) bool { return true }
+
+func (UniformStrategy) Plan(sr *io.SectionReader, size uint64, layout Layout) (Plan, error) {
+	if layout.Slices <= 0 {
+		return Plan{}, errors.New("invalid number of slices")
+	}
+	if layout.SliceSize*2 > size {
+		return Plan{}, errors.New("slice size too large for blob")
+	}
+
+	midSize := size - (layout.SliceSize * 2)
+	divResult := midSize / uint64(layout.Slices)
+	if divResult < layout.SliceSize {
+		return Plan{}, errors.New("slice offset would be negative")
+	}
+	sliceOffsetCalc := divResult - layout.SliceSize
+	const maxInt64 = 1<<63 - 1
+	if sliceOffsetCalc > maxInt64 || layout.SliceSize > maxInt64 {
+		return Plan{}, errors.New("slice offset overflow")
+	}
+	sliceOffset := int64(sliceOffsetCalc)
+	sliceSize := int64(layout.SliceSize)
+
+	regions := make([]Region, 0, layout.Slices+2)
+
+	// head
+	regions = append(regions, Region{Offset: 0, Size: sliceSize})
+
+	// mid-slice crisis
+	offset := sliceSize
+	for i := 0; i < layout.Slices; i++ {
+		offset += sliceOffset
+		regions = append(regions, Region{Offset: offset, Size: sliceSize})
+		offset += sliceSize
+	}
+
+	// tail
+	regions = append(regions, Region{Offset: int64(size) - sliceSize, Size: sliceSize})
+
+	return Plan{
+		Regions:     regions,
+		SliceOffset: sliceOffset,
+		MidSize:     midSize,
+	}, nil
+}
+
+// VideoStrategy samples video containers (MP4/MOV & Matroska/WebM). The
+// container header and, for MP4, the 'moov' index are hashed along with
+// evenly spaced chunks of the payload. Videos encoded with the same tool
+// often share identical headers & trailers, the index & payload do not.
+type VideoStrategy struct{}
+
+var (
+	mp4FileType  = []byte("ftyp")
+	matroskaEBML = []byte{0x1A, 0x45, 0xDF, 0xA3}
+)
+
+func (VideoStrategy) Name() string { return StrategyVideo }
+
+func (VideoStrategy) Detect(header []byte, size uint64) bool {
+	return isMP4(header) || bytes.HasPrefix(header, matroskaEBML)
+}
+
+func (VideoStrategy) Plan(sr *io.SectionReader, size uint64, layout Layout) (Plan, error) {
+	sliceSize := int64(layout.SliceSize)
+	headerSize := int64(VideoHeaderSize)
+	tailOffset := int64(size) - sliceSize
+	span := tailOffset - headerSize
+
+	if layout.Slices <= 0 || span < int64(layout.Slices)*sliceSize {
+		return UniformStrategy{}.Plan(sr, size, layout)
+	}
+
+	regions := make([]Region, 0, layout.Slices+3)
+	regions = append(regions, Region{Offset: 0, Size: headerSize})
+
+	bucket := span / int64(layout.Slices)
+	for i := 0; i < layout.Slices; i++ {
+		offset := headerSize + bucket*int64(i) + (bucket-sliceSize)/2
+		regions = append(regions, Region{Offset: offset, Size: sliceSize})
+	}
+	regions = append(regions, Region{Offset: tailOffset, Size: sliceSize})
+
+	if header := make([]byte, 8); readHeader(sr, header) && isMP4(header) {
+		if moov, ok := findMP4Box(sr, size, "moov"); ok && moov.Size <= MaxVideoIndexSize {
+			regions = append(regions, moov)
+		}
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Offset < regions[j].Offset
+	})
+
+	return Plan{Regions: regions}, nil
+}
+
+func isMP4(header []byte) bool {
+	return len(header) >= 8 && bytes.Equal(header[4:8], mp4FileType)
+}
+
+// findMP4Box walks the top-level boxes of an ISO-BMFF blob looking for kind.
+func findMP4Box(sr *io.SectionReader, size uint64, kind string) (Region, bool) {
+	const boxHeaderSize = 8
+	const maxBoxes = 1024
+
+	header := make([]byte, 16)
+	offset := int64(0)
+	limit := int64(size)
+
+	for i := 0; i < maxBoxes && offset+boxHeaderSize <= limit; i++ {
+		if _, err := sr.ReadAt(header[:boxHeaderSize], offset); err != nil {
+			return Region{}, false
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxKind := string(header[4:8])
+
+		switch boxSize {
+		case 0:
+			// box extends to the end of the blob
+			boxSize = limit - offset
+		case 1:
+			// 64-bit largesize follows the type
+			if _, err := sr.ReadAt(header[boxHeaderSize:16], offset+boxHeaderSize); err != nil {
+				return Region{}, false
+			}
+			largeSize := binary.BigEndian.Uint64(header[boxHeaderSize:16])
+			if largeSize > uint64(limit) {
+				return Region{}, false
+			}
+			boxSize = int64(largeSize)
+		}
+
+		if boxSize < boxHeaderSize || offset+boxSize > limit {
+			return Region{}, false
+		}
+		if boxKind == kind {
+			return Region{Offset: offset, Size: boxSize}, true
+		}
+		offset += boxSize
+	}
+	return Region{}, false
+}
+
+// ArchiveStrategy samples ZIP based formats (zip, jar, docx, xlsx, epub, apk
+// etc.) by hashing the central directory, which records the name, size &
+// CRC-32 of every entry in the archive.
+type ArchiveStrategy struct{}
+
+var (
+	zipLocalHeader     = []byte{'P', 'K', 0x03, 0x04}
+	zipEndOfCentralDir = []byte{'P', 'K', 0x05, 0x06}
+)
+
+func (ArchiveStrategy) Name() string { return StrategyArchive }
+
+func (ArchiveStrategy) Detect(header []byte, size uint64) bool {
+	return bytes.HasPrefix(header, zipLocalHeader) || bytes.HasPrefix(header, zipEndOfCentralDir)
+}
+
+func (ArchiveStrategy) Plan(sr *io.SectionReader, size uint64, layout Layout) (Plan, error) {
+	const eocdSize = 22
+	const maxCommentSize = 0xFFFF
+
+	search := int64(eocdSize + maxCommentSize)
+	if search > int64(size) {
+		search = int64(size)
+	}
+	tailOffset := int64(size) - search
+	tail := make([]byte, search)
+	if _, err := sr.ReadAt(tail, tailOffset); err != nil && !errors.Is(err, io.EOF) {
+		return Plan{}, err
+	}
+
+	index := bytes.LastIndex(tail, zipEndOfCentralDir)
+	if index < 0 || len(tail)-index < eocdSize {
+		return UniformStrategy{}.Plan(sr, size, layout)
+	}
+	eocd := tail[index:]
+	eocdOffset := tailOffset + int64(index)
+	cdSize := int64(binary.LittleEndian.Uint32(eocd[12:16]))
+	cdOffset := int64(binary.LittleEndian.Uint32(eocd[16:20]))
+
+	// ZIP64 archives & corrupt offsets are sampled uniformly
+	if cdOffset == 0xFFFFFFFF || cdSize > MaxCentralDirectorySize || cdOffset+cdSize > eocdOffset {
+		return UniformStrategy{}.Plan(sr, size, layout)
+	}
+
+	return Plan{
+		Regions: []Region{
+			{Offset: cdOffset, Size: cdSize},
+			{Offset: eocdOffset, Size: int64(size) - eocdOffset},
+		},
+	}, nil
+}
+
+func readHeader(sr *io.SectionReader, header []byte) bool {
+	n, err := sr.ReadAt(header, 0)
+	return n == len(header) && (err == nil || errors.Is(err, io.EOF))
+}
@@ -0,0 +1,187 @@
+package slicer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+func TestStrategy_ResolvesByHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		data     []byte
+	}{
+		{name: "random binary", data: randomBytes(1024000), expected: StrategyUniform},
+		{name: "zip", data: buildZip(t, 4, 512*1024), expected: StrategyArchive},
+		{name: "mp4", data: buildMP4(randomBytes(1024000)), expected: StrategyVideo},
+		{name: "matroska", data: append([]byte{0x1A, 0x45, 0xDF, 0xA3}, randomBytes(1024000)...), expected: StrategyVideo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slicer := New(algorithms.Xxhash)
+			sr := io.NewSectionReader(bytes.NewReader(tt.data), 0, int64(len(tt.data)))
+
+			header, release := sniffHeader(sr)
+			defer release()
+
+			actual := slicer.resolveStrategy(header, uint64(len(tt.data)), &Options{})
+
+			if actual.Name() != tt.expected {
+				t.Errorf("expected strategy %s, got %s", tt.expected, actual.Name())
+			}
+		})
+	}
+}
+
+func TestStrategy_DisableFormatsUsesUniform(t *testing.T) {
+	data := buildZip(t, 4, 512*1024)
+	slicer := New(algorithms.Xxhash)
+	sr := io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+
+	header, release := sniffHeader(sr)
+	defer release()
+
+	actual := slicer.resolveStrategy(header, uint64(len(data)), &Options{DisableFormats: true})
+
+	if actual.Name() != StrategyUniform {
+		t.Errorf("expected strategy %s, got %s", StrategyUniform, actual.Name())
+	}
+}
+
+func TestStrategy_UniformMatchesLegacyOffsets(t *testing.T) {
+	plan, err := UniformStrategy{}.Plan(nil, 1024000, Layout{Slices: DefaultSlices, SliceSize: DefaultSliceSize})
+	if err != nil {
+		t.Fatalf("Unexpected plan error %v", err)
+	}
+
+	expected := []int64{0, 251904, 503808, 755712, 1007616, 1015808}
+	if len(plan.Regions) != len(expected) {
+		t.Fatalf("expected %d regions, got %d", len(expected), len(plan.Regions))
+	}
+	for i, region := range plan.Regions {
+		if region.Offset != expected[i] {
+			t.Errorf("region %d expected offset %d, got %d", i, expected[i], region.Offset)
+		}
+	}
+}
+
+func TestStrategy_TextIsFullHashed(t *testing.T) {
+	data := []byte(strings.Repeat("lorem ipsum dolor sit amet\n", 10000))
+	stats := sliceBytes(t, data)
+
+	if !stats.HashedFullFile {
+		t.Error("expected text to be full hashed")
+	}
+	if stats.Strategy != StrategyText {
+		t.Errorf("expected strategy %s, got %s", StrategyText, stats.Strategy)
+	}
+}
+
+func TestStrategy_ArchiveHashesCentralDirectory(t *testing.T) {
+	data := buildZip(t, 4, 512*1024)
+	stats := sliceBytes(t, data)
+
+	if stats.HashedFullFile {
+		t.Error("expected archive to be sliced")
+	}
+	if stats.Strategy != StrategyArchive {
+		t.Errorf("expected strategy %s, got %s", StrategyArchive, stats.Strategy)
+	}
+	if len(stats.SliceOffsets) != 2 {
+		t.Errorf("expected central directory & eocd regions, got %v", stats.SliceOffsets)
+	}
+}
+
+func TestStrategy_ArchiveWithDifferentEntriesDiffer(t *testing.T) {
+	a := buildZip(t, 4, 512*1024)
+	b := buildZip(t, 4, 512*1024)
+
+	if bytes.Equal(sliceBytes(t, a).Hash, sliceBytes(t, b).Hash) {
+		t.Error("expected archives with different entries to hash differently")
+	}
+}
+
+func TestStrategy_VideoWithSharedHeaderAndTrailerDiffer(t *testing.T) {
+	header := randomBytes(VideoHeaderSize)
+	trailer := randomBytes(DefaultSliceSize)
+
+	build := func() []byte {
+		payload := append(append(append([]byte{}, header...), randomBytes(2*1024*1024)...), trailer...)
+		return buildMP4(payload)
+	}
+
+	a := sliceBytes(t, build())
+	b := sliceBytes(t, build())
+
+	if a.Strategy != StrategyVideo {
+		t.Errorf("expected strategy %s, got %s", StrategyVideo, a.Strategy)
+	}
+	if bytes.Equal(a.Hash, b.Hash) {
+		t.Error("expected videos with different payloads to hash differently")
+	}
+}
+
+func TestStrategy_FindMP4Box(t *testing.T) {
+	data := buildMP4(randomBytes(4096))
+	sr := io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+
+	moov, ok := findMP4Box(sr, uint64(len(data)), "moov")
+	if !ok {
+		t.Fatal("expected to find moov box")
+	}
+	if moov.Offset+moov.Size != int64(len(data)) {
+		t.Errorf("expected moov to be the last box, got %+v of %d", moov, len(data))
+	}
+}
+
+func sliceBytes(t *testing.T, data []byte) SlicerStats {
+	t.Helper()
+	slicer := New(algorithms.Xxhash)
+	sr := io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+	stats := SlicerStats{}
+	if err := slicer.Slice(context.Background(), sr, &Options{}, &stats); err != nil {
+		t.Fatalf("Unexpected Slicer error %v", err)
+	}
+	return stats
+}
+
+func buildZip(t *testing.T, entries, entrySize int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < entries; i++ {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "entry-" + string(rune('a'+i)), Method: zip.Store})
+		if err != nil {
+			t.Fatalf("Unexpected zip error %v", err)
+		}
+		if _, err := w.Write(randomBytes(entrySize)); err != nil {
+			t.Fatalf("Unexpected zip error %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Unexpected zip error %v", err)
+	}
+	return buf.Bytes()
+}
+
+func buildMP4(payload []byte) []byte {
+	box := func(kind string, body []byte) []byte {
+		b := make([]byte, 8, 8+len(body))
+		binary.BigEndian.PutUint32(b[0:4], uint32(8+len(body)))
+		copy(b[4:8], kind)
+		return append(b, body...)
+	}
+	var out []byte
+	out = append(out, box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2"))...)
+	out = append(out, box("mdat", payload)...)
+	out = append(out, box("moov", randomBytes(2048))...)
+	return out
+}
@@ -0,0 +1,77 @@
+package smash
+
+import (
+	core "github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/pkg/indexer"
+)
+
+// Observer is told about a scan as it happens, to stream results elsewhere
+// without waiting for the Result. All but GroupFormed & ScanFinished are called
+// from the scanning goroutines so must be safe for concurrent use.
+type Observer interface {
+	// FileIndexed is called with the path of every file found, before it's hashed
+	FileIndexed(path string)
+	// FileHashed is called with every file once it's hashed, including empty files
+	FileHashed(file File)
+	// FileFailed is called with every file or location that couldn't be read
+	FileFailed(fail Fail)
+	// GroupFormed is called once for every group of duplicates when the scan is
+	// done, before ScanFinished. Files are in FileHashed as they're hashed
+	GroupFormed(group Group)
+	// ScanFinished is called with the result before Scan returns it
+	ScanFinished(result Result)
+}
+
+// NopObserver Ignores everything, embed it to observe only some events.
+type NopObserver struct{}
+
+func (NopObserver) FileIndexed(string)  {}
+func (NopObserver) FileHashed(File)     {}
+func (NopObserver) FileFailed(Fail)     {}
+func (NopObserver) GroupFormed(Group)   {}
+func (NopObserver) ScanFinished(Result) {}
+
+// observer Passes the app's events on to the options' observers & callbacks.
+type observer struct {
+	core.NopObserver
+	options Options
+}
+
+func (o *observer) FileIndexed(file *indexer.FileFS) {
+	for _, observer := range o.options.Observers {
+		observer.FileIndexed(file.FullName)
+	}
+}
+
+func (o *observer) FileSmashed(file core.File) {
+	if o.options.OnFile == nil && len(o.options.Observers) == 0 {
+		return
+	}
+	f := newFile(file)
+	if o.options.OnFile != nil {
+		o.options.OnFile(f)
+	}
+	for _, observer := range o.options.Observers {
+		observer.FileHashed(f)
+	}
+}
+
+func (o *observer) FileFailed(name string, err error) {
+	fail := newFail(name, err)
+	if o.options.OnFail != nil {
+		o.options.OnFail(fail)
+	}
+	for _, observer := range o.options.Observers {
+		observer.FileFailed(fail)
+	}
+}
+
+func (o *observer) GroupFormed(hash string, files []core.File) {
+	if len(o.options.Observers) == 0 {
+		return
+	}
+	group := newGroup(hash, files)
+	for _, observer := range o.options.Observers {
+		observer.GroupFormed(group)
+	}
+}
@@ -0,0 +1,367 @@
+// Package smash finds duplicate files, it's the library behind the smash CLI
+// without any of its console output.
+//
+//	scanner, err := smash.NewScanner(smash.DefaultOptions("/mnt/assets"))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := scanner.Scan(ctx)
+//
+// Files are compared by slicing (see pkg/slicer) unless DisableSlicing is set,
+// so a duplicate is very likely but only certain for files hashed in full.
+package smash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	core "github.com/thushan/smash/internal/smash"
+	"github.com/thushan/smash/pkg/algorithms"
+	"github.com/thushan/smash/pkg/indexer"
+	"github.com/thushan/smash/pkg/slicer"
+)
+
+const DefaultProgressInterval = time.Second
+
+// Options configure a Scanner, DefaultOptions matches the CLI's defaults.
+type Options struct {
+	// OnProgress is called every ProgressInterval while scanning
+	OnProgress func(Progress)
+	// OnFile is called with every file as it's hashed, from the scanning goroutines
+	OnFile func(File)
+	// OnFail is called with every file or location that couldn't be read, from the scanning goroutines
+	OnFail func(Fail)
+	// Observers are told about the scan as it happens, see Observer
+	Observers []Observer
+	// Logger records skipped files at info & every hashed file at debug, nothing is logged without one
+	Logger *slog.Logger
+	// Locations are the directories to scan
+	Locations []string
+	// Algorithms hash files, duplicates are found by the first & the rest are added to File.Digests
+	Algorithms   []algorithms.Algorithm
+	ExcludeDirs  []string
+	ExcludeFiles []string
+	// MinSize & MaxSize limit the files hashed (in bytes), 0 is no limit
+	MinSize int64
+	MaxSize int64
+	// SliceSize, SliceThreshold & Slices default to slicer.DefaultSliceSize, slicer.DefaultThreshold & slicer.DefaultSlices
+	SliceSize      int64
+	SliceThreshold int64
+	// ProgressInterval defaults to DefaultProgressInterval
+	ProgressInterval time.Duration
+	Slices           int
+	// Workers hash files concurrently, defaults to the number of CPUs
+	Workers         int
+	Recurse         bool
+	DisableSlicing  bool
+	DisableMeta     bool
+	DisableAutoText bool
+	DisableFormats  bool
+	AdaptiveSlices  bool
+	ParallelReads   bool
+	// IgnoreEmpty leaves Result.Empty out, empty files are still counted but never duplicates
+	IgnoreEmpty  bool
+	IgnoreHidden bool
+	IgnoreSystem bool
+}
+
+// File is a hashed file, or an empty one.
+type File struct {
+	ModTime time.Time
+	// Digests of each of Options.Algorithms, only fully hashed files have them
+	Digests map[string]string
+	// Path of the file, the location joined with RelativePath
+	Path         string
+	Location     string
+	RelativePath string
+	Hash         string
+	Strategy     string
+	ContentType  string
+	Size         uint64
+	FullHash     bool
+	Empty        bool
+}
+
+// Group is a set of files with the same hash.
+type Group struct {
+	// ID is stable across scans, it's the same as a report's group id
+	ID    string
+	Hash  string
+	Files []File
+	Size  uint64
+}
+
+// Fail is a file or location that couldn't be read.
+type Fail struct {
+	Err  error
+	Path string
+	// Code says why, ie. permission or vanished, see the report's failCode
+	Code string
+}
+
+// Progress of a running scan.
+type Progress struct {
+	// Location being indexed
+	Location string
+	Files    int64
+	Fails    int64
+}
+
+// Result of a scan, Groups are ordered by the space they'd reclaim.
+type Result struct {
+	// Skipped counts the files & directories that weren't hashed by reason, ie. hidden or size
+	Skipped map[string]int64
+	Groups  []Group
+	// Empty files found, unless Options.IgnoreEmpty is set
+	Empty            []File
+	Fails            []Fail
+	Elapsed          time.Duration
+	TotalFiles       int64
+	UniqueFiles      int64
+	DuplicateFiles   int64
+	ReclaimableBytes uint64
+	// Partial is set when the scan was cancelled, only files hashed by then are included
+	Partial bool
+}
+
+type Scanner struct {
+	options   Options
+	locations []indexer.LocationFS
+}
+
+// DefaultOptions Returns the options the CLI uses by default for the locations.
+func DefaultOptions(locations ...string) Options {
+	return Options{
+		Locations:        locations,
+		Algorithms:       []algorithms.Algorithm{algorithms.Xxhash},
+		Slices:           slicer.DefaultSlices,
+		SliceSize:        slicer.DefaultSliceSize,
+		SliceThreshold:   slicer.DefaultThreshold,
+		Workers:          runtime.NumCPU(),
+		ProgressInterval: DefaultProgressInterval,
+		Recurse:          true,
+		IgnoreEmpty:      true,
+		IgnoreHidden:     true,
+		IgnoreSystem:     true,
+	}
+}
+
+// NewScanner Returns a scanner for the options, the locations must exist.
+func NewScanner(options Options) (*Scanner, error) {
+	if len(options.Locations) == 0 {
+		return nil, errors.New("at least one location is required")
+	}
+	if len(options.Algorithms) == 0 {
+		options.Algorithms = []algorithms.Algorithm{algorithms.Xxhash}
+	}
+	for _, algorithm := range options.Algorithms {
+		if !algorithm.Available() {
+			return nil, fmt.Errorf("hash algorithm %s isn't registered, see algorithms.Register", algorithm)
+		}
+	}
+	if options.Workers <= 0 {
+		options.Workers = runtime.NumCPU()
+	}
+	if options.ProgressInterval <= 0 {
+		options.ProgressInterval = DefaultProgressInterval
+	}
+	if options.Slices <= 0 {
+		options.Slices = slicer.DefaultSlices
+	}
+	if options.SliceSize <= 0 {
+		options.SliceSize = slicer.DefaultSliceSize
+	}
+	if options.SliceThreshold <= 0 {
+		options.SliceThreshold = slicer.DefaultThreshold
+	}
+	locations := make([]indexer.LocationFS, 0, len(options.Locations))
+	for _, location := range options.Locations {
+		if _, err := os.Stat(location); err != nil {
+			return nil, fmt.Errorf("invalid location %q: %w", location, err)
+		}
+		locations = append(locations, *indexer.NewLocationFS(indexer.Local, location, os.DirFS(location)))
+	}
+	return &Scanner{options: options, locations: locations}, nil
+}
+
+// Scan Finds the duplicates in the scanner's locations. When the context is
+// cancelled the result covers the files hashed by then, with Partial set, and
+// the context's error is returned.
+func (s *Scanner) Scan(ctx context.Context) (Result, error) {
+	app := &core.App{
+		Flags:     s.flags(),
+		Args:      s.options.Locations,
+		Locations: s.locations,
+		Progress:  core.NewAppProgress(),
+		Logger:    s.options.Logger,
+	}
+	if len(s.options.Observers) > 0 || s.options.OnFile != nil || s.options.OnFail != nil {
+		app.Observers = []core.Observer{&observer{options: s.options}}
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	if onProgress := s.options.OnProgress; onProgress != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(s.options.ProgressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					onProgress(newProgress(app.Progress))
+				case <-done:
+					onProgress(newProgress(app.Progress))
+					return
+				}
+			}
+		}()
+	}
+
+	err := app.Run(ctx)
+	close(done)
+	wg.Wait()
+
+	if app.Summary == nil {
+		return Result{}, err
+	}
+	result := newResult(app)
+	for _, o := range s.options.Observers {
+		o.ScanFinished(result)
+	}
+	if result.Partial {
+		return result, ctx.Err()
+	}
+	return result, err
+}
+
+func (s *Scanner) flags() *core.Flags {
+	o := s.options
+	algorithmIDs := make([]int, len(o.Algorithms))
+	for i, algorithm := range o.Algorithms {
+		algorithmIDs[i] = int(algorithm)
+	}
+	return &core.Flags{
+		Algorithms:      algorithmIDs,
+		ExcludeDir:      o.ExcludeDirs,
+		ExcludeFile:     o.ExcludeFiles,
+		MinSize:         o.MinSize,
+		MaxSize:         o.MaxSize,
+		Slices:          o.Slices,
+		SliceSize:       o.SliceSize,
+		SliceThreshold:  o.SliceThreshold,
+		MaxWorkers:      o.Workers,
+		ShowTop:         10,
+		ProgressUpdate:  1,
+		Recurse:         o.Recurse,
+		DisableSlicing:  o.DisableSlicing,
+		DisableMeta:     o.DisableMeta,
+		DisableAutoText: o.DisableAutoText,
+		DisableFormats:  o.DisableFormats,
+		AdaptiveSlices:  o.AdaptiveSlices,
+		ParallelReads:   o.ParallelReads,
+		IgnoreEmpty:     o.IgnoreEmpty,
+		IgnoreHidden:    o.IgnoreHidden,
+		IgnoreSystem:    o.IgnoreSystem,
+		HideOutput:      true,
+	}
+}
+
+func newResult(app *core.App) Result {
+	summary := app.Summary
+	session := app.Session
+	result := Result{
+		Elapsed:          time.Duration(summary.ElapsedTime),
+		TotalFiles:       summary.TotalFiles,
+		UniqueFiles:      summary.UniqueFiles,
+		DuplicateFiles:   summary.DuplicateFiles,
+		ReclaimableBytes: summary.DuplicateFileSize,
+		Partial:          summary.Partial,
+		Skipped:          make(map[string]int64, len(summary.Skipped)),
+	}
+	for reason, count := range summary.Skipped {
+		result.Skipped[string(reason)] = count
+	}
+
+	// only duplicates are left in the session once it's summarised
+	session.Dupes.Range(func(hash string, dupes *core.DuplicateFiles) bool {
+		result.Groups = append(result.Groups, newGroup(hash, dupes.Files))
+		return true
+	})
+	sort.Slice(result.Groups, func(i, j int) bool {
+		gi, gj := result.Groups[i], result.Groups[j]
+		ri, rj := gi.Size*uint64(len(gi.Files)-1), gj.Size*uint64(len(gj.Files)-1)
+		if ri != rj {
+			return ri > rj
+		}
+		return gi.ID < gj.ID
+	})
+
+	if !app.Flags.IgnoreEmpty {
+		for _, file := range session.Empty.Files {
+			result.Empty = append(result.Empty, newFile(file))
+		}
+	}
+	sort.Slice(result.Empty, func(i, j int) bool {
+		return result.Empty[i].Path < result.Empty[j].Path
+	})
+
+	session.Fails.Range(func(name string, err error) bool {
+		result.Fails = append(result.Fails, newFail(name, err))
+		return true
+	})
+	sort.Slice(result.Fails, func(i, j int) bool {
+		return result.Fails[i].Path < result.Fails[j].Path
+	})
+	return result
+}
+
+func newGroup(hash string, files []core.File) Group {
+	group := Group{Hash: hash, Files: make([]File, len(files))}
+	for i, file := range files {
+		group.Files[i] = newFile(file)
+	}
+	sort.Slice(group.Files, func(i, j int) bool {
+		return group.Files[i].Path < group.Files[j].Path
+	})
+	group.Size = group.Files[0].Size
+	group.ID = core.GroupID(hash, group.Size)
+	return group
+}
+
+func newFile(file core.File) File {
+	return File{
+		ModTime:      file.Meta.ModTime,
+		Digests:      file.Digests,
+		Path:         filepath.Join(file.Location, file.Path),
+		Location:     file.Location,
+		RelativePath: filepath.ToSlash(file.Path),
+		Hash:         file.Hash,
+		Strategy:     file.Strategy,
+		ContentType:  file.ContentType,
+		Size:         file.FileSize,
+		FullHash:     file.FullHash,
+		Empty:        file.EmptyFile,
+	}
+}
+
+func newFail(name string, err error) Fail {
+	return Fail{Path: name, Err: err, Code: string(core.ClassifyFail(err))}
+}
+
+func newProgress(progress *core.AppProgress) Progress {
+	return Progress{
+		Location: progress.Location(),
+		Files:    progress.Files.Value(),
+		Fails:    progress.Fails.Value(),
+	}
+}
@@ -0,0 +1,211 @@
+package smash
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/thushan/smash/pkg/algorithms"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScannerScan(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"DSC19841.ARW":        "smash",
+		"backup/DSC19841.ARW": "smash",
+		"DSC19842.ARW":        "smash hits",
+		"unique.txt":          "unique",
+		"empty.txt":           "",
+	})
+
+	options := DefaultOptions(dir)
+	options.Algorithms = []algorithms.Algorithm{algorithms.Xxhash, algorithms.Sha256}
+	options.IgnoreEmpty = false
+	var files, progress atomic.Int64
+	options.OnFile = func(File) { files.Add(1) }
+	options.OnProgress = func(Progress) { progress.Add(1) }
+
+	scanner, err := NewScanner(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.TotalFiles != 5 {
+		t.Errorf("expected 5 files, got %d", result.TotalFiles)
+	}
+	if files.Load() != 5 {
+		t.Errorf("expected OnFile for 5 files, got %d", files.Load())
+	}
+	if progress.Load() == 0 {
+		t.Error("expected OnProgress to be called once finished")
+	}
+	if len(result.Groups) != 1 || len(result.Groups[0].Files) != 2 {
+		t.Fatalf("expected 1 group of 2 files, got %+v", result.Groups)
+	}
+	group := result.Groups[0]
+	if group.Files[0].Path != filepath.Join(dir, "DSC19841.ARW") || group.Files[1].RelativePath != "backup/DSC19841.ARW" {
+		t.Errorf("expected DSC19841.ARW & backup/DSC19841.ARW, got %+v", group.Files)
+	}
+	if group.Files[0].Digests[algorithms.Sha256.String()] == "" {
+		t.Errorf("expected a sha256 digest, got %v", group.Files[0].Digests)
+	}
+	if result.ReclaimableBytes != 5 || result.DuplicateFiles != 1 {
+		t.Errorf("expected 1 duplicate of 5 bytes, got %d of %d bytes", result.DuplicateFiles, result.ReclaimableBytes)
+	}
+	if result.Skipped["hidden"] != 0 {
+		t.Errorf("expected nothing hidden to skip, got %v", result.Skipped)
+	}
+	if len(result.Empty) != 1 || !result.Empty[0].Empty {
+		t.Errorf("expected 1 empty file, got %+v", result.Empty)
+	}
+}
+
+func TestScannerZeroOptions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"DSC19841.ARW": "smash",
+		"DSC19842.ARW": "smash",
+		"empty.txt":    "",
+	})
+
+	tests := []struct {
+		name        string
+		ignoreEmpty bool
+		empty       int
+	}{
+		{name: "Should list empty files", ignoreEmpty: false, empty: 1},
+		{name: "Should leave empty files out with IgnoreEmpty", ignoreEmpty: true, empty: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner, err := NewScanner(Options{Locations: []string{dir}, IgnoreEmpty: tt.ignoreEmpty})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := scanner.Scan(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Groups) != 1 || result.TotalFiles != 3 {
+				t.Errorf("expected 1 group of the 3 files, got %+v", result)
+			}
+			if len(result.Empty) != tt.empty {
+				t.Errorf("expected %d empty files, got %+v", tt.empty, result.Empty)
+			}
+		})
+	}
+}
+
+func TestScannerScanCancelled(t *testing.T) {
+	scanner, err := NewScanner(DefaultOptions(writeFiles(t, map[string]string{"DSC19841.ARW": "smash"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := scanner.Scan(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if !result.Partial {
+		t.Error("expected a partial result")
+	}
+}
+
+func TestScannerScanLeavesGOMAXPROCS(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"DSC19841.ARW": "smash"})
+	previous := runtime.GOMAXPROCS(1)
+	defer runtime.GOMAXPROCS(previous)
+
+	scanner, err := NewScanner(DefaultOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("expected GOMAXPROCS to be left at 1, got %d", got)
+	}
+}
+
+func TestNewScannerRejectsMissingLocations(t *testing.T) {
+	if _, err := NewScanner(Options{}); err == nil {
+		t.Error("expected an error without locations")
+	}
+	if _, err := NewScanner(DefaultOptions(filepath.Join(t.TempDir(), "missing"))); err == nil {
+		t.Error("expected an error for a missing location")
+	}
+}
+
+type groupObserver struct {
+	NopObserver
+	groups  map[string]Group
+	indexed atomic.Int64
+	result  Result
+	sync.Mutex
+}
+
+func (o *groupObserver) FileIndexed(string) { o.indexed.Add(1) }
+func (o *groupObserver) GroupFormed(group Group) {
+	o.Lock()
+	defer o.Unlock()
+	o.groups[group.ID] = group
+}
+func (o *groupObserver) ScanFinished(result Result) { o.result = result }
+
+func TestScannerObservers(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"DSC19841.ARW":        "smash",
+		"backup/DSC19841.ARW": "smash",
+		"unique.txt":          "unique",
+	})
+	observer := &groupObserver{groups: map[string]Group{}}
+	options := DefaultOptions(dir)
+	options.Observers = []Observer{observer}
+
+	scanner, err := NewScanner(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if observer.indexed.Load() != 3 {
+		t.Errorf("expected 3 files indexed, got %d", observer.indexed.Load())
+	}
+	if len(observer.groups) != 1 {
+		t.Fatalf("expected 1 group, got %+v", observer.groups)
+	}
+	if group, ok := observer.groups[result.Groups[0].ID]; !ok || len(group.Files) != 2 {
+		t.Errorf("expected the result's group of 2 files, got %+v", observer.groups)
+	}
+	if observer.result.TotalFiles != result.TotalFiles {
+		t.Errorf("expected %d files in the finished result, got %d", result.TotalFiles, observer.result.TotalFiles)
+	}
+}